@@ -0,0 +1,93 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/eklitzke/jump/db"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage database backups",
+}
+
+// backupListCmd represents the backup list command
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List database backups",
+	Run: func(cmd *cobra.Command, args []string) {
+		backups, err := db.ListBackups(backupDir)
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", backupDir).Msg("failed to list backups")
+		}
+		for _, backup := range backups {
+			fmt.Printf("%s  %s  %8d\n", backup.ID, backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Size)
+		}
+	},
+}
+
+// backupRestoreCmd represents the backup restore command
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore ID",
+	Short: "Restore a database backup",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		backup, ok := db.FindBackup(backupDir, args[0])
+		if !ok {
+			log.Fatal().Str("id", args[0]).Str("dir", backupDir).Msg("no such backup")
+		}
+		f, err := os.Open(backup.Path)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", backup.Path).Msg("failed to open backup")
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Error().Err(err).Str("path", backup.Path).Msg("failed to close backup")
+			}
+		}()
+		restored := db.NewDatabase(f, dbOptions())
+		if err := restored.LoadError(); err != nil {
+			log.Fatal().Err(err).Str("path", backup.Path).Msg("backup is corrupt")
+		}
+
+		// if the current database is corrupt, start over with an empty
+		// database
+		if err := handle.LoadError(); err != nil && !isCorrupt(err) {
+			log.Fatal().Err(err).Str("path", dbPath).Msg("refusing to overwrite a database written by a newer version of jump")
+		}
+		if handle.LoadError() != nil {
+			handle = db.NewDatabase(&bytes.Buffer{}, dbOptions())
+		}
+		entries := restored.GetWeights()
+		handle.Replace(entries)
+		forceBackup = true
+		log.Info().Str("id", backup.ID).Int("entries", len(entries)).Msg("restored database backup")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
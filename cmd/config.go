@@ -19,15 +19,28 @@ package cmd
 import (
 	"io/ioutil"
 
+	"github.com/eklitzke/jump/db"
 	"gopkg.in/yaml.v2"
 )
 
 type config struct {
-	ExcludePatterns []string `yaml:"ExcludePatterns"`
+	ExcludePatterns []string     `yaml:"ExcludePatterns"`
+	Backups         backupConfig `yaml:"Backups"`
+
+	// SearchPasses overrides the alpha of search passes by name; an alpha
+	// of zero disables the pass.
+	SearchPasses map[string]float64 `yaml:"SearchPasses"`
+}
+
+type backupConfig struct {
+	Hourly int `yaml:"Hourly"` // number of hourly backups to keep
+	Daily  int `yaml:"Daily"`  // number of daily backups to keep
 }
 
 func loadConfig() *config {
-	c := &config{}
+	c := &config{
+		Backups: backupConfig{Hourly: 24, Daily: 14},
+	}
 	yamlFile, err := ioutil.ReadFile(cfgFile)
 	if err != nil {
 		return c
@@ -35,3 +48,11 @@ func loadConfig() *config {
 	_ = yaml.Unmarshal(yamlFile, c)
 	return c
 }
+
+// backupPolicy returns the configured backup retention policy.
+func (c *config) backupPolicy() db.BackupPolicy {
+	return db.BackupPolicy{
+		Hourly: c.Backups.Hourly,
+		Daily:  c.Backups.Daily,
+	}
+}
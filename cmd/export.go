@@ -0,0 +1,70 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var exportFormat string
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [FILE|-]",
+	Short: "Export the database in a portable format",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "-"
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		var out io.Writer = os.Stdout
+		if path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				log.Fatal().Err(err).Str("path", path).Msg("failed to create export file")
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					log.Error().Err(err).Str("path", path).Msg("failed to close export file")
+				}
+			}()
+			out = f
+		}
+
+		w := bufio.NewWriter(out)
+		if err := db.Export(w, handle.GetWeights(), exportFormat); err != nil {
+			log.Fatal().Err(err).Str("format", exportFormat).Msg("failed to export database")
+		}
+		if err := w.Flush(); err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("failed to flush export")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "Export format ("+strings.Join(db.ExportFormats, "|")+")")
+}
@@ -17,39 +17,111 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/eklitzke/jump/db"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
+var importMerge bool
+var importReplace bool
+var importFrom string
+var importFormat string
+
+// importer knows how to find and load another tool's database.
+type importer struct {
+	find func() string
+	load func(io.Reader) ([]db.Entry, error)
+}
+
+// importers maps the supported --from values to their importers.
+var importers = map[string]importer{
+	"autojump": {db.FindAutojumpDatabase, db.LoadAutojumpDatabase},
+	"fasd":     {db.FindFasdDatabase, db.LoadFasdDatabase},
+	"z":        {db.FindZDatabase, db.LoadZDatabase},
+	"zoxide":   {db.FindZoxideDatabase, db.LoadZoxideDatabase},
+}
+
+// importerNames returns the sorted list of importer names.
+func importerNames() []string {
+	var names []string
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // importCmd represents the import command
 var importCmd = &cobra.Command{
-	Use:   "import",
-	Short: "Import an autojump database",
+	Use:   "import [FILE|-]",
+	Short: "Import an autojump, z, fasd or zoxide database",
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		path := db.FindAutojumpDatabase()
-		if path == "" {
-			log.Fatal().Msg("unable to find autojump database")
+		if importReplace && importMerge && cmd.Flags().Changed("merge") {
+			log.Fatal().Msg("--merge and --replace are mutually exclusive")
 		}
-		f, err := os.Open(path)
-		if err != nil {
-			log.Fatal().Err(err).Str("path", path).Msg("failed to open autojump database")
+		imp, ok := importers[importFrom]
+		if !ok {
+			log.Fatal().Str("from", importFrom).Strs("supported", importerNames()).Msg("unknown database type")
 		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				log.Error().Err(err).Str("path", path).Msg("failed to close autojump database")
+		if cmd.Flags().Changed("format") {
+			// jump's own export formats have no default location
+			if cmd.Flags().Changed("from") {
+				log.Fatal().Msg("--from and --format are mutually exclusive")
 			}
-		}()
-		newWeights, err := db.LoadAutojumpDatabase(f)
+			if len(args) == 0 {
+				log.Fatal().Msg("a file (or - for stdin) is required with --format")
+			}
+			imp = importer{load: func(r io.Reader) ([]db.Entry, error) { return db.LoadExport(r, importFormat) }}
+		}
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		} else {
+			if path = imp.find(); path == "" {
+				log.Fatal().Str("from", importFrom).Msg("unable to find database")
+			}
+			log.Info().Str("from", importFrom).Str("path", path).Msg("found database")
+		}
+
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatal().Err(err).Str("from", importFrom).Str("path", path).Msg("failed to open database")
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					log.Error().Err(err).Str("path", path).Msg("failed to close database")
+				}
+			}()
+			r = f
+		}
+		newWeights, err := imp.load(r)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to import autojump database")
+			log.Fatal().Err(err).Str("from", importFrom).Str("path", path).Msg("failed to import database")
+		}
+		forceBackup = true
+		if importReplace || !importMerge {
+			handle.Replace(newWeights)
+			fmt.Printf("replaced database with %d entries\n", len(newWeights))
+			return
 		}
-		handle.Replace(newWeights)
+		summary := handle.Import(newWeights)
+		fmt.Printf("added %d, updated %d, skipped %d entries\n", summary.Added, summary.Updated, summary.Skipped)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().BoolVar(&importMerge, "merge", true, "Merge imported entries with existing entries")
+	importCmd.Flags().StringVar(&importFrom, "from", "autojump", "Database type to import ("+strings.Join(importerNames(), "|")+")")
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "json", "Import a jump export in this format ("+strings.Join(db.ExportFormats, "|")+")")
+	importCmd.Flags().BoolVar(&importReplace, "replace", false, "Replace all existing entries with the imported entries")
 }
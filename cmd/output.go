@@ -0,0 +1,115 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+)
+
+// outputFormats lists the supported search output formats.
+var outputFormats = []string{"plain", "json", "tsv", "null"}
+
+// entryPrinter writes search results to an output stream.
+type entryPrinter func(w io.Writer, entries []db.Entry) error
+
+// newEntryPrinter returns a printer for the given format, or for tmpl if it's
+// non-empty. Templates are executed once per entry, with a newline appended.
+func newEntryPrinter(format, tmpl string, verbose bool) (entryPrinter, error) {
+	if tmpl != "" {
+		t, err := template.New("entry").Parse(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		return func(w io.Writer, entries []db.Entry) error {
+			for _, entry := range entries {
+				if err := t.Execute(w, entry); err != nil {
+					return err
+				}
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+
+	switch format {
+	case "plain":
+		return func(w io.Writer, entries []db.Entry) error {
+			for _, entry := range entries {
+				var err error
+				if verbose {
+					_, err = fmt.Fprintf(w, "%10.4f  %s\n", entry.Weight, entry.Path)
+				} else {
+					_, err = fmt.Fprintln(w, entry.Path)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case "json":
+		return func(w io.Writer, entries []db.Entry) error {
+			if entries == nil {
+				entries = []db.Entry{}
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}, nil
+	case "tsv":
+		return func(w io.Writer, entries []db.Entry) error {
+			for _, entry := range entries {
+				weight := strconv.FormatFloat(entry.Weight, 'g', -1, 64)
+				updatedAt := entry.UpdatedAt.Format(time.RFC3339)
+				if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", weight, updatedAt, db.EscapeTSV(entry.Path)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case "null":
+		return func(w io.Writer, entries []db.Entry) error {
+			for _, entry := range entries {
+				if _, err := fmt.Fprintf(w, "%s\x00", entry.Path); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (expected %s)", format, strings.Join(outputFormats, "|"))
+}
+
+// printEntries writes entries to w using the given printer.
+func printEntries(w io.Writer, printer entryPrinter, entries []db.Entry) error {
+	bw := bufio.NewWriter(w)
+	if err := printer(bw, entries); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eklitzke/jump/db"
+	"golang.org/x/sys/unix"
+)
+
+// errPickerCancelled is returned when the user cancels the picker.
+var errPickerCancelled = errors.New("cancelled")
+
+// Keys understood by the picker.
+const (
+	keyCtrlC     = 0x03
+	keyCtrlJ     = 0x0a
+	keyCtrlK     = 0x0b
+	keyEnter     = 0x0d
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyCtrlU     = 0x15
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+	keyCtrlH     = 0x08
+)
+
+// picker holds the state of the interactive picker.
+type picker struct {
+	candidates []string // all candidates, best first
+	filter     []rune   // the current filter text
+	matches    []string // candidates matching the filter
+	selected   int      // index of the selected match
+	rows       int      // the number of matches that fit on the terminal
+	cols       int      // the width of the terminal, or 0 if unknown
+}
+
+// newPicker creates a picker for the candidates.
+func newPicker(candidates []string) *picker {
+	p := &picker{candidates: candidates, rows: len(candidates)}
+	p.refilter()
+	return p
+}
+
+// refilter recomputes the matches after the filter changes. Candidates are
+// kept in rank order; the filter is matched as a case insensitive
+// subsequence.
+func (p *picker) refilter() {
+	filter := strings.ToLower(string(p.filter))
+	p.matches = p.matches[:0]
+	for _, candidate := range p.candidates {
+		if filter == "" || db.FuzzyScore(strings.ToLower(candidate), filter) > 0 {
+			p.matches = append(p.matches, candidate)
+		}
+	}
+	p.selected = 0
+}
+
+// handleInput processes a chunk of input read from the terminal. It returns
+// true when the picker is done, with an error if it was cancelled.
+func (p *picker) handleInput(input []byte) (bool, error) {
+	for len(input) > 0 {
+		if input[0] == keyEscape {
+			if len(input) == 1 {
+				// a lone escape, rather than the start of a sequence
+				return true, errPickerCancelled
+			}
+			key, n := parseEscape(input)
+			switch key {
+			case 'A': // up arrow
+				p.move(-1)
+			case 'B': // down arrow
+				p.move(1)
+			}
+			input = input[n:]
+			continue
+		}
+
+		switch input[0] {
+		case keyEnter, keyCtrlJ:
+			if len(p.matches) == 0 {
+				return true, errPickerCancelled
+			}
+			return true, nil
+		case keyCtrlC:
+			return true, errPickerCancelled
+		case keyCtrlP, keyCtrlK:
+			p.move(-1)
+		case keyCtrlN:
+			p.move(1)
+		case keyBackspace, keyCtrlH:
+			if len(p.filter) > 0 {
+				p.filter = p.filter[:len(p.filter)-1]
+				p.refilter()
+			}
+		case keyCtrlU:
+			p.filter = p.filter[:0]
+			p.refilter()
+		default:
+			r, size := utf8.DecodeRune(input)
+			if r != utf8.RuneError && r >= ' ' {
+				p.filter = append(p.filter, r)
+				p.refilter()
+			}
+			input = input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+	return false, nil
+}
+
+// parseEscape parses the escape sequence at the start of input, which is an
+// escape followed by at least one more byte. It returns the final byte of CSI
+// and SS3 sequences (e.g. 'A' for the up arrow), or 0 for other sequences, and
+// the length of the sequence. Sequences cut off by the end of input are
+// consumed entirely.
+func parseEscape(input []byte) (byte, int) {
+	switch input[1] {
+	case '[':
+		// any parameter and intermediate bytes are followed by a final
+		// byte in the range @ to ~
+		for i := 2; i < len(input); i++ {
+			if input[i] >= '@' && input[i] <= '~' {
+				return input[i], i + 1
+			}
+		}
+		return 0, len(input)
+	case 'O':
+		if len(input) > 2 {
+			return input[2], 3
+		}
+		return 0, len(input)
+	}
+	// alt modified keys are an escape followed by the key
+	return 0, 2
+}
+
+// resize fits the picker to a terminal of the given size, leaving a row for
+// the prompt.
+func (p *picker) resize(rows, cols int) {
+	p.rows = rows - 1
+	if p.rows < 1 {
+		p.rows = 1
+	}
+	p.cols = cols
+}
+
+// move moves the selection, clamping it to the matches.
+func (p *picker) move(delta int) {
+	p.selected += delta
+	if p.selected >= len(p.matches) {
+		p.selected = len(p.matches) - 1
+	}
+	if p.selected < 0 {
+		p.selected = 0
+	}
+}
+
+// render draws the picker below the cursor, leaving the cursor on the prompt
+// line.
+func (p *picker) render(w *bufio.Writer) {
+	fmt.Fprintf(w, "\r\x1b[J> %s", string(p.filter))
+
+	// only draw as many matches as fit on the terminal, scrolling to keep
+	// the selection visible, so that the terminal itself never scrolls
+	start, end := 0, len(p.matches)
+	if end > p.rows {
+		end = p.rows
+	}
+	if p.selected >= end {
+		start, end = p.selected-p.rows+1, p.selected+1
+	}
+	for i := start; i < end; i++ {
+		match := p.matches[i]
+		if p.cols > 0 && utf8.RuneCountInString(match) >= p.cols {
+			// long lines would wrap onto more rows
+			match = string([]rune(match)[:p.cols-1])
+		}
+		if i == p.selected {
+			fmt.Fprintf(w, "\r\n\x1b[7m%s\x1b[0m", match)
+		} else {
+			fmt.Fprintf(w, "\r\n%s", match)
+		}
+	}
+	if end > start {
+		fmt.Fprintf(w, "\x1b[%dA", end-start)
+	}
+	fmt.Fprintf(w, "\r\x1b[%dC", 2+len(p.filter))
+}
+
+// runPicker lets the user interactively pick one of the candidates, drawing
+// the UI on the controlling terminal so that stdout can be captured.
+func runPicker(candidates []string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tty.Close() }()
+
+	// put the terminal in raw mode, restoring it when we're done
+	fd := int(tty.Fd())
+	orig, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return "", err
+	}
+	raw := *orig
+	raw.Iflag &^= unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return "", err
+	}
+	defer func() { _ = unix.IoctlSetTermios(fd, ioctlSetTermios, orig) }()
+
+	p := newPicker(candidates)
+	w := bufio.NewWriter(tty)
+	buf := make([]byte, 64)
+	for {
+		if size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil && size.Row > 0 {
+			p.resize(int(size.Row), int(size.Col))
+		}
+		p.render(w)
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+		n, err := tty.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		done, err := p.handleInput(buf[:n])
+		if !done {
+			continue
+		}
+
+		// clear the picker before returning
+		fmt.Fprint(w, "\r\x1b[J")
+		if flushErr := w.Flush(); flushErr != nil {
+			return "", flushErr
+		}
+		if err != nil {
+			return "", err
+		}
+		return p.matches[p.selected], nil
+	}
+}
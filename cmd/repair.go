@@ -0,0 +1,101 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/eklitzke/jump/db"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// repairCmd represents the repair command
+var repairCmd = &cobra.Command{
+	Use:   "repair [FILE]",
+	Short: "Salvage entries from a corrupt database",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := quarantinePath
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			path = findCorruptDatabase()
+		}
+		if path == "" {
+			log.Fatal().Msg("no corrupt database found to repair")
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("failed to open corrupt database")
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Error().Err(err).Str("path", path).Msg("failed to close corrupt database")
+			}
+		}()
+		salvaged, err := db.Salvage(f)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("failed to salvage database")
+		}
+
+		// if the current database is the corrupt one, start over with an
+		// empty database
+		if err := handle.LoadError(); err != nil && !isCorrupt(err) {
+			log.Fatal().Err(err).Str("path", dbPath).Msg("refusing to overwrite a database written by a newer version of jump")
+		}
+		if handle.LoadError() != nil {
+			handle = db.NewDatabase(&bytes.Buffer{}, dbOptions())
+		}
+
+		// keep current entries, adding salvaged entries that are missing
+		entries := handle.GetWeights()
+		existing := make(map[string]bool)
+		for _, entry := range entries {
+			existing[entry.Path] = true
+		}
+		added := 0
+		for _, entry := range salvaged {
+			if !existing[entry.Path] {
+				entries = append(entries, entry)
+				added++
+			}
+		}
+		handle.Replace(entries)
+		forceBackup = true
+		log.Info().Str("path", path).Int("salvaged", len(salvaged)).Int("added", added).Msg("repaired database")
+	},
+}
+
+// findCorruptDatabase finds the most recent corrupt database.
+func findCorruptDatabase() string {
+	matches, err := filepath.Glob(dbPath + ".corrupt.*")
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1]
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+}
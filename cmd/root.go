@@ -19,10 +19,13 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/eklitzke/jump/db"
 	isatty "github.com/mattn/go-isatty"
@@ -34,11 +37,20 @@ import (
 
 var cfgFile string
 var dbPath string
+var backupDir string
 var debug bool
 var timeMatching bool
+var fuzzy bool
+var proximity bool
+var smartCase bool
+var foldDiacritics bool
 var logCaller bool
 var logLevel string
+var lockTimeout time.Duration
 var handle db.Database
+var dbLock *db.FileLock
+var quarantinePath string
+var forceBackup bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -57,12 +69,37 @@ func Execute() {
 		log.Fatal().Err(err).Msg("fatal error running command")
 	}
 
-	// Save the database; this is a no-op if the database hasn't been
-	// mutated.
+	// A database that failed to load is never saved; fail loudly so the
+	// user knows to run jump repair (or upgrade jump).
+	if handle != nil && handle.LoadError() != nil {
+		if !isCorrupt(handle.LoadError()) {
+			log.Fatal().Err(handle.LoadError()).Str("path", dbPath).Msg("database was written by a newer version of jump")
+		}
+		log.Fatal().Err(handle.LoadError()).Str("corrupt", quarantinePath).Msg("database is corrupt, run \"jump repair\" to salvage it")
+	}
+
+	closeDB()
+}
+
+// closeDB saves the database, which is a no-op if it hasn't been mutated, and
+// then releases the database lock. Commands that keep running after they're
+// done with the database (like the interactive picker) call this early so they
+// don't block other jump processes.
+func closeDB() {
 	if handle != nil {
 		if err := saveDB(); err != nil {
 			log.Fatal().Err(err).Msg("failed to save database")
 		}
+		handle = nil
+	}
+
+	// Release the database lock now that the new database file (if any)
+	// has been renamed into place.
+	if dbLock != nil {
+		if err := dbLock.Release(); err != nil {
+			log.Warn().Err(err).Msg("failed to release database lock")
+		}
+		dbLock = nil
 	}
 }
 
@@ -73,10 +110,22 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", db.ConfigPath(), "config file")
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "database", "D", db.DatabasePath(), "database file")
+	rootCmd.PersistentFlags().StringVar(&backupDir, "backup-dir", db.BackupDir(), "database backup directory")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
 	rootCmd.PersistentFlags().BoolVar(&logCaller, "log-caller", false, "include caller info in log messages")
 	rootCmd.PersistentFlags().BoolVar(&timeMatching, "time-matching", true, "enable time matching in searches")
+	rootCmd.PersistentFlags().BoolVar(&fuzzy, "fuzzy", false, "enable fuzzy matching in searches")
+	rootCmd.PersistentFlags().BoolVar(&proximity, "proximity", false, "prefer search results near the current directory")
+	rootCmd.PersistentFlags().BoolVar(&smartCase, "smart-case", true, "ignore case in searches unless the query has uppercase")
+	rootCmd.PersistentFlags().BoolVar(&foldDiacritics, "fold-diacritics", false, "ignore diacritics in searches")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "info", "the log level")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 5*time.Second, "how long to wait for the database lock")
+
+	// Search settings can also be set in the config file.
+	_ = viper.BindPFlag("Fuzzy", rootCmd.PersistentFlags().Lookup("fuzzy"))
+	_ = viper.BindPFlag("Proximity", rootCmd.PersistentFlags().Lookup("proximity"))
+	_ = viper.BindPFlag("SmartCase", rootCmd.PersistentFlags().Lookup("smart-case"))
+	_ = viper.BindPFlag("FoldDiacritics", rootCmd.PersistentFlags().Lookup("fold-diacritics"))
 
 	// Start logging initialization now, so that log messages are properly
 	// formatted on the console if other initialization tasks fail.
@@ -116,6 +165,19 @@ func initLogging() {
 }
 
 func initDBHandle() {
+	// Hold the database lock from the time we read the database until the
+	// time it's saved, so concurrent updates aren't lost.
+	ensureDirectory(filepath.Dir(dbPath))
+	lockPath := db.LockPath(dbPath)
+	lock, err := db.AcquireLock(lockPath, lockTimeout)
+	if err != nil {
+		if err == db.ErrLockTimeout {
+			log.Fatal().Err(err).Str("path", lockPath).Dur("timeout", lockTimeout).Msg("database is locked by another jump process")
+		}
+		log.Fatal().Err(err).Str("path", lockPath).Msg("failed to lock database")
+	}
+	dbLock = lock
+
 	var r io.Reader
 	dbFile, err := os.Open(dbPath)
 	if err != nil {
@@ -134,10 +196,100 @@ func initDBHandle() {
 		}()
 		r = dbFile
 	}
-	handle = db.NewDatabase(r, db.Options{
-		Debug:        debug,
-		TimeMatching: timeMatching,
-	})
+	handle = db.NewDatabase(r, dbOptions())
+
+	// If the database is corrupt, move it aside so it can be repaired and
+	// so that it doesn't get clobbered. Databases written by a newer version
+	// of jump are left alone, since they're fine.
+	if isCorrupt(handle.LoadError()) && dbFile != nil {
+		quarantinePath = fmt.Sprintf("%s.corrupt.%s", dbPath, time.Now().Format("20060102T150405"))
+		if err := os.Rename(dbPath, quarantinePath); err != nil {
+			log.Fatal().Err(err).Str("path", dbPath).Msg("failed to move corrupt database aside")
+		}
+		log.Error().Err(handle.LoadError()).Str("path", quarantinePath).Msg("moved corrupt database aside")
+	}
+}
+
+// isCorrupt checks if a database load error means the database is corrupt, as
+// opposed to having been written by a newer version of jump.
+func isCorrupt(err error) bool {
+	return err != nil && !errors.Is(err, db.ErrUnsupportedVersion)
+}
+
+// dbOptions returns the database options from the command line flags.
+func dbOptions() db.Options {
+	opts := db.Options{
+		Debug:          debug,
+		TimeMatching:   timeMatching,
+		Fuzzy:          viper.GetBool("Fuzzy"),
+		SmartCase:      viper.GetBool("SmartCase"),
+		FoldDiacritics: viper.GetBool("FoldDiacritics"),
+		Cwd:            searchCwd,
+		Proximity:      viper.GetBool("Proximity"),
+		RepoRoots:      searchRepo,
+	}
+	if searchDirs || !searchFiles {
+		opts.Kinds = append(opts.Kinds, db.KindDir)
+	}
+	if searchFiles {
+		opts.Kinds = append(opts.Kinds, db.KindFile)
+	}
+
+	// restrict searches to the current repository
+	if searchInRepo {
+		dir := searchCwd
+		if dir == "" {
+			var err error
+			if dir, err = os.Getwd(); err != nil {
+				log.Fatal().Err(err).Msg("failed to getcwd")
+			}
+		}
+		if opts.Repo = db.FindRepoRoot(dir); opts.Repo == "" {
+			log.Fatal().Str("dir", dir).Msg("not in a repository")
+		}
+	}
+
+	// select the ranking algorithm from the config file
+	if name := viper.GetString("ranking.algorithm"); name != "" {
+		ranker, ok := db.RankerByName(name)
+		if !ok {
+			log.Fatal().Str("algorithm", name).Strs("supported", db.RankerNames()).Msg("unknown ranking algorithm")
+		}
+		opts.Ranker = ranker
+	}
+
+	// configure aging from the config file, dropping entries that decay
+	// below a weight of 1 unless configured otherwise
+	opts.Aging = db.Aging{
+		HalfLife:  viper.GetDuration("aging.halfLife"),
+		MaxTotal:  viper.GetFloat64("aging.maxTotal"),
+		Factor:    viper.GetFloat64("aging.factor"),
+		Threshold: 1,
+	}
+	if viper.IsSet("aging.threshold") {
+		opts.Aging.Threshold = viper.GetFloat64("aging.threshold")
+	}
+	if opts.Aging.HalfLife < 0 || opts.Aging.MaxTotal < 0 || opts.Aging.Factor < 0 || opts.Aging.Factor >= 1 {
+		log.Fatal().Interface("aging", opts.Aging).Msg("invalid aging configuration")
+	}
+
+	// apply search pass overrides from the config file
+	if passes := loadConfig().SearchPasses; len(passes) > 0 {
+		pipeline := db.DefaultPipeline(opts)
+		for name, alpha := range passes {
+			var ok bool
+			if alpha > 0 {
+				ok = pipeline.SetAlpha(name, alpha)
+			} else {
+				ok = pipeline.Remove(name)
+			}
+			if !ok {
+				log.Warn().Str("pass", name).Msg("unknown search pass in config file")
+			}
+		}
+		opts.Pipeline = pipeline
+	}
+	return opts
 }
 
 func saveDB() error {
@@ -150,6 +302,12 @@ func saveDB() error {
 	dir := filepath.Dir(dbPath)
 	ensureDirectory(dir)
 
+	// replay our changes on top of the current on-disk database, in case
+	// it was updated after we loaded it
+	if err := mergeDB(); err != nil {
+		return err
+	}
+
 	// create the temporary file in the same directory as the destination
 	// file, to ensure that the rename operation is atomic
 	temp, err := ioutil.TempFile(dir, ".jump.bak")
@@ -180,6 +338,11 @@ func saveDB() error {
 		return err
 	}
 
+	// back up the previous database before replacing it
+	if err := db.RotateBackup(dbPath, backupDir, loadConfig().backupPolicy(), forceBackup); err != nil {
+		log.Warn().Err(err).Str("dir", backupDir).Msg("failed to back up database")
+	}
+
 	// atomic rename
 	if err := os.Rename(tempName, dbPath); err != nil {
 		log.Error().Err(err).Str("dbpath", dbPath).Str("tempfile", tempName).Msg("failed to rename db file")
@@ -189,6 +352,28 @@ func saveDB() error {
 	return nil
 }
 
+// mergeDB merges the on-disk database into the handle.
+func mergeDB() error {
+	dbFile, err := os.Open(dbPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		log.Error().Err(err).Str("path", dbPath).Msg("failed to open database file for merge")
+		return err
+	}
+	defer func() {
+		if err := dbFile.Close(); err != nil {
+			log.Warn().Err(err).Str("path", dbPath).Msg("failed to close db file")
+		}
+	}()
+	if err := handle.Merge(bufio.NewReader(dbFile)); err != nil {
+		log.Error().Err(err).Str("path", dbPath).Msg("failed to merge database")
+		return err
+	}
+	return nil
+}
+
 // ensureDirectory ensures that a directory exists
 func ensureDirectory(dir string) {
 	_, err := os.Stat(dir)
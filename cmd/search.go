@@ -18,33 +18,147 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/eklitzke/jump/db"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 var verbose bool
 var searchCount int
+var searchRegex bool
+var searchGlob bool
+var searchExplain bool
+var searchInteractive bool
+var searchFormat string
+var searchTemplate string
+var searchCwd string
+var searchRepo bool
+var searchInRepo bool
+var searchFiles bool
+var searchDirs bool
+
+// pickerCount is the default number of candidates offered by the picker.
+const pickerCount = 20
 
 var searchCmd = &cobra.Command{
-	Use:   "search",
+	Use:   "search QUERY...",
 	Short: "Search the database for matches",
 	Run: func(cmd *cobra.Command, args []string) {
-		var printer func(db.Entry)
-		if verbose {
-			printer = func(e db.Entry) { fmt.Printf("%10.4f  %s\n", e.Weight, e.Path) }
-		} else {
-			printer = func(e db.Entry) { fmt.Println(e.Path) }
+		if searchRegex && searchGlob {
+			log.Fatal().Msg("--regex and --glob are mutually exclusive")
+		}
+		if searchExplain {
+			if searchRegex || searchGlob {
+				log.Fatal().Msg("--explain can't be used with --regex or --glob")
+			}
+			printExplanations(handle.Explain(args...))
+			return
+		}
+		if searchInteractive {
+			if !cmd.Flags().Changed("num-results") {
+				searchCount = pickerCount
+			}
+			pickEntry(args)
+			return
+		}
+		if searchTemplate != "" && cmd.Flags().Changed("format") {
+			log.Fatal().Msg("--format and --template are mutually exclusive")
 		}
-		entries := handle.Search(searchCount, args...)
-		for _, entry := range entries {
-			printer(entry)
+		printer, err := newEntryPrinter(searchFormat, searchTemplate, verbose)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid output format")
+		}
+		if err := printEntries(os.Stdout, printer, searchEntries(args)); err != nil {
+			log.Fatal().Err(err).Msg("failed to print search results")
 		}
 	},
 }
 
+// searchEntries runs the search in the selected query mode.
+func searchEntries(args []string) []db.Entry {
+	if !searchRegex && !searchGlob {
+		return handle.Search(searchCount, args...)
+	}
+	if len(args) != 1 {
+		log.Fatal().Int("args", len(args)).Msg("pattern searches take exactly one pattern")
+	}
+	pattern := args[0]
+	compile, mode := db.RegexCompare, "regex"
+	if searchGlob {
+		compile, mode = db.GlobCompare, "glob"
+	}
+	cmp, err := compile(pattern)
+	if err != nil {
+		log.Fatal().Err(err).Str(mode, pattern).Msg("invalid pattern")
+	}
+	return handle.SearchCompare(searchCount, pattern, cmp)
+}
+
+// pickEntry lets the user pick one of the search results interactively, and
+// prints the chosen path. Nothing is printed if the user cancels.
+func pickEntry(args []string) {
+	var candidates []string
+	for _, entry := range searchEntries(args) {
+		candidates = append(candidates, entry.Path)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// the picker can stay open indefinitely, so don't hold the database
+	// lock while it's open
+	closeDB()
+	choice, err := runPicker(candidates)
+	if err == errPickerCancelled {
+		return
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to run interactive picker")
+	}
+	fmt.Println(choice)
+}
+
+// printExplanations prints a table explaining how each candidate was scored.
+func printExplanations(explanations []db.Explanation) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tWEIGHT\tBETA\tPASSES\tBOOST\tSTATUS\tPATH")
+	for _, e := range explanations {
+		var passes []string
+		for _, p := range e.Passes {
+			if p.Score == 1 {
+				passes = append(passes, fmt.Sprintf("%s×%g", p.Pass, p.Alpha))
+			} else {
+				passes = append(passes, fmt.Sprintf("%s×%g×%.3f", p.Pass, p.Alpha, p.Score))
+			}
+		}
+		status := "ok"
+		if e.Rejected != "" {
+			status = "rejected: " + e.Rejected
+		}
+		fmt.Fprintf(w, "%.4f\t%.4f\t%.6f\t%s\t%.3g\t%s\t%s\n", e.Score, e.Weight, e.Beta, strings.Join(passes, " "), e.Proximity, status, e.Path)
+	}
+	if err := w.Flush(); err != nil {
+		log.Warn().Err(err).Msg("failed to write explanations")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(searchCmd)
 	searchCmd.Flags().IntVarP(&searchCount, "num-results", "n", 1, "Number of database entries to keep")
 	searchCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show verbose results")
+	searchCmd.Flags().BoolVar(&searchRegex, "regex", false, "Match paths against a regular expression")
+	searchCmd.Flags().BoolVar(&searchGlob, "glob", false, "Match paths against a shell glob")
+	searchCmd.Flags().BoolVarP(&searchInteractive, "interactive", "i", false, "Interactively pick from the best matches")
+	searchCmd.Flags().StringVar(&searchCwd, "cwd", "", "Current directory, ranked below all other matches")
+	searchCmd.Flags().BoolVar(&searchRepo, "repo", false, "Only search repository roots")
+	searchCmd.Flags().BoolVar(&searchInRepo, "in-repo", false, "Only search paths in the current repository")
+	searchCmd.Flags().BoolVar(&searchFiles, "files", false, "Search files")
+	searchCmd.Flags().BoolVar(&searchDirs, "dirs", false, "Search directories (the default, unless --files is used)")
+	searchCmd.Flags().StringVarP(&searchFormat, "format", "f", "plain", "Output format ("+strings.Join(outputFormats, "|")+")")
+	searchCmd.Flags().StringVar(&searchTemplate, "template", "", "Go template used to print each entry")
+	searchCmd.Flags().BoolVar(&searchExplain, "explain", false, "Explain how each candidate was scored")
 }
@@ -0,0 +1,153 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var statsTop int
+var statsStaleDays int
+var statsWeeks int
+var statsJSON bool
+
+// histogramWidth is the width of the longest bar in the visits histogram.
+const histogramWidth = 50
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize database usage",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statsTop < 0 {
+			log.Fatal().Int("top", statsTop).Msg("number of entries to show must not be negative")
+		}
+		if statsWeeks < 0 || statsWeeks*7 > db.MaxVisitDays {
+			log.Fatal().Int("weeks", statsWeeks).Int("max", db.MaxVisitDays/7).Msg("number of weeks is out of range")
+		}
+		stats := db.ComputeStats(handle.GetWeights(), db.StatsOptions{
+			Top:        statsTop,
+			StaleAfter: time.Duration(statsStaleDays) * 24 * time.Hour,
+			Days:       statsWeeks * 7,
+			Now:        time.Now(),
+		})
+		if statsJSON {
+			if err := newStdoutJSONEncoder().Encode(stats); err != nil {
+				log.Fatal().Err(err).Msg("failed to json encode stats")
+			}
+			return
+		}
+		printStats(stats)
+	},
+}
+
+// printStats prints a human readable summary of the stats.
+func printStats(stats db.Stats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	size := "missing"
+	if st, err := os.Stat(dbPath); err == nil {
+		size = formatBytes(st.Size())
+	}
+	fmt.Fprintf(w, "Database:\t%s (%s)\n", dbPath, size)
+	fmt.Fprintf(w, "Entries:\t%d (%d directories, %d files)\n", stats.Entries, stats.Dirs, stats.Files)
+	fmt.Fprintf(w, "Missing:\t%d entries no longer exist\n", stats.Missing)
+	fmt.Fprintf(w, "Stale:\t%d entries not visited in %d days\n", stats.Stale, statsStaleDays)
+	if len(stats.Weights) > 0 {
+		var percentiles []string
+		for _, p := range stats.Weights {
+			percentiles = append(percentiles, fmt.Sprintf("p%d=%.4g", p.Percent, p.Weight))
+		}
+		fmt.Fprintf(w, "Weights:\t%s\n", strings.Join(percentiles, " "))
+	}
+
+	if len(stats.MostVisited) > 0 {
+		fmt.Fprintln(w, "\nMost visited:")
+		for _, e := range stats.MostVisited {
+			fmt.Fprintf(w, "  %d\t%s\n", e.Visits, e.Path)
+		}
+	}
+	if len(stats.MostRecent) > 0 {
+		fmt.Fprintln(w, "\nMost recent:")
+		for _, e := range stats.MostRecent {
+			fmt.Fprintf(w, "  %s ago\t%s\n", formatAge(time.Since(e.LastVisit())), e.Path)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Warn().Err(err).Msg("failed to write stats")
+	}
+
+	if len(stats.Visits) > 0 {
+		fmt.Printf("\nVisits per day (last %d weeks):\n", statsWeeks)
+		most := 0
+		for _, day := range stats.Visits {
+			if day.Visits > most {
+				most = day.Visits
+			}
+		}
+		for _, day := range stats.Visits {
+			bar := 0
+			if most > 0 {
+				bar = (day.Visits*histogramWidth + most - 1) / most
+			}
+			fmt.Printf("  %s  %-*s %d\n", day.Day.Format("2006-01-02 Mon"), histogramWidth, strings.Repeat("#", bar), day.Visits)
+		}
+	}
+}
+
+// formatBytes formats a size in bytes for humans.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatAge formats a duration coarsely, e.g. "5m" or "3d".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().IntVarP(&statsTop, "top", "n", 5, "Number of most visited and most recent entries to show")
+	statsCmd.Flags().IntVar(&statsStaleDays, "stale-days", 90, "Count entries not visited in this many days")
+	statsCmd.Flags().IntVar(&statsWeeks, "weeks", 4, fmt.Sprintf("Number of weeks of visits to show, at most %d", db.MaxVisitDays/7))
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print stats as JSON")
+}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/eklitzke/jump/db"
@@ -26,6 +27,7 @@ import (
 )
 
 var updateWeight float64
+var updateFile bool
 
 // updateCmd represents the add command
 var updateCmd = &cobra.Command{
@@ -35,6 +37,9 @@ var updateCmd = &cobra.Command{
 		if updateWeight == 0 {
 			log.Fatal().Msg("ignoring update command for 0 weight")
 		}
+		if len(args) == 0 && updateFile {
+			log.Fatal().Msg("no file supplied")
+		}
 		if len(args) == 0 {
 			dir, err := os.Getwd()
 			if err != nil {
@@ -43,13 +48,27 @@ var updateCmd = &cobra.Command{
 			args = append(args, dir)
 		}
 
-		// try to update each argument, first checking that it exists and is a directory
+		// try to update each argument, first checking that it exists and is a
+		// directory (or a file, with --file)
 		config := loadConfig()
+		check := db.CheckIsDir
+		if updateFile {
+			check = db.CheckIsFile
+		}
 
 	dirLoop:
 		for _, dir := range args {
+			// store absolute paths, since editor hooks often supply
+			// relative ones
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				log.Warn().Err(err).Str("path", dir).Msg("failed to get absolute path")
+				continue
+			}
+			dir = abs
+
 			// ensure we have a directory
-			if err := db.CheckIsDir(dir); err != nil {
+			if err := check(dir); err != nil {
 				continue
 			}
 
@@ -69,5 +88,6 @@ var updateCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().BoolVar(&updateFile, "file", false, "Update files instead of directories")
 	updateCmd.Flags().Float64VarP(&updateWeight, "weight", "w", 15, "Weight to adjust by (may be negative)")
 }
@@ -42,6 +42,7 @@ var varsCmd = &cobra.Command{
 		c := loadConfig()
 		display := configDisplay{
 			Paths: map[string]string{
+				"backups":  backupDir,
 				"config":   cfgFile,
 				"database": dbPath,
 			},
@@ -0,0 +1,95 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"time"
+)
+
+// defaultAgingFactor is the factor weights are scaled by when their total
+// exceeds Aging.MaxTotal, if no factor is configured.
+const defaultAgingFactor = .9
+
+// Aging decays database weights over time, independently of the ranking
+// algorithm. The zero value doesn't age anything.
+type Aging struct {
+	// HalfLife, if positive, is how long it takes for weights to halve.
+	HalfLife time.Duration
+
+	// MaxTotal, if positive, is the total weight above which all weights
+	// are scaled by Factor, like z does.
+	MaxTotal float64
+	Factor   float64
+
+	// Threshold is the weight below which aged entries are dropped.
+	Threshold float64
+}
+
+// Enabled checks if the aging does anything.
+func (a Aging) Enabled() bool {
+	return a.HalfLife > 0 || a.MaxTotal > 0
+}
+
+// Apply ages the weights in place as of now, returning true if anything
+// changed. Each weight remembers when it was last aged, so applying the
+// aging repeatedly doesn't decay weights any faster. Weights that it decays
+// below the threshold are dropped.
+func (a Aging) Apply(weights map[string]Weight, now time.Time) bool {
+	if !a.Enabled() {
+		return false
+	}
+	changed := false
+	decayed := make(map[string]bool)
+	if a.HalfLife > 0 {
+		for path, w := range weights {
+			since := w.AgedAt
+			if since.IsZero() {
+				since = w.UpdatedAt
+			}
+			elapsed := now.Sub(since)
+			if elapsed <= 0 {
+				continue
+			}
+			w.Value *= math.Pow(.5, float64(elapsed)/float64(a.HalfLife))
+			w.AgedAt = now
+			weights[path] = w
+			decayed[path] = true
+			changed = true
+		}
+	}
+	if a.MaxTotal > 0 && totalWeight(weights) > a.MaxTotal {
+		factor := a.Factor
+		if factor <= 0 || factor >= 1 {
+			factor = defaultAgingFactor
+		}
+		for path, w := range weights {
+			w.Value *= factor
+			weights[path] = w
+			decayed[path] = true
+		}
+		changed = true
+	}
+	// only drop the weights aged here, not ones that were already small
+	for path := range decayed {
+		if weights[path].Value < a.Threshold {
+			delete(weights, path)
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAgingHalfLife(c *C) {
+	now := time.Now().UTC()
+	aging := db.Aging{HalfLife: 24 * time.Hour, Threshold: 1}
+	weights := map[string]db.Weight{
+		"/new":   {Value: 8, UpdatedAt: now},
+		"/day":   {Value: 8, UpdatedAt: now.Add(-24 * time.Hour)},
+		"/month": {Value: 8, UpdatedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	c.Assert(aging.Apply(weights, now), Equals, true)
+	c.Assert(weights, HasLen, 2)
+	c.Assert(weights["/new"].Value, Equals, 8.)
+	c.Assert(weights["/day"].Value, Equals, 4.)
+
+	// aging again only decays by the time since the last aging
+	c.Assert(aging.Apply(weights, now.Add(24*time.Hour)), Equals, true)
+	c.Assert(weights["/new"].Value, Equals, 4.)
+	c.Assert(weights["/day"].Value, Equals, 2.)
+	c.Assert(aging.Apply(weights, now.Add(24*time.Hour)), Equals, false)
+}
+
+func (s *MySuite) TestAgingMaxTotal(c *C) {
+	weights := make(map[string]db.Weight)
+	for i := 0; i < 10; i++ {
+		weights[fmt.Sprintf("/path/%d", i)] = db.Weight{Value: 100}
+	}
+	weights["/small"] = db.Weight{Value: 1}
+
+	c.Assert(db.Aging{}.Apply(weights, time.Now()), Equals, false)
+	c.Assert(db.Aging{MaxTotal: 2000}.Apply(weights, time.Now()), Equals, false)
+
+	aging := db.Aging{MaxTotal: 1000, Threshold: 1}
+	c.Assert(aging.Apply(weights, time.Now()), Equals, true)
+	c.Assert(weights, HasLen, 10)
+	c.Assert(weights["/path/0"].Value, Equals, 90.)
+}
+
+func (s *MySuite) TestAgingKeepsUndecayedWeights(c *C) {
+	now := time.Now().UTC()
+	aging := db.Aging{HalfLife: 24 * time.Hour, Threshold: 1}
+	weights := map[string]db.Weight{
+		"/lowered": {Value: .5, UpdatedAt: now},
+		"/aged":    {Value: .5, UpdatedAt: now.Add(-time.Hour), AgedAt: now},
+		"/old":     {Value: 1.5, UpdatedAt: now.Add(-24 * time.Hour)},
+	}
+
+	// weights that are already below the threshold, such as after a
+	// negative adjustment, are only dropped once they're decayed
+	c.Assert(aging.Apply(weights, now), Equals, true)
+	c.Assert(weights, HasLen, 2)
+	c.Assert(weights["/lowered"].Value, Equals, .5)
+	c.Assert(weights["/aged"].Value, Equals, .5)
+
+	c.Assert(aging.Apply(weights, now.Add(time.Hour)), Equals, true)
+	c.Assert(weights, HasLen, 0)
+}
+
+func (s *MySuite) TestDatabaseAging(c *C) {
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Replace([]db.Entry{
+		{Path: "/popular", Weight: 10000, UpdatedAt: old},
+		{Path: "/forgotten", Weight: 10, UpdatedAt: old},
+	})
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+
+	// weights are aged when the database is loaded
+	opts := db.Options{Aging: db.Aging{HalfLife: 24 * time.Hour, Threshold: 1}}
+	handle = db.NewGobDatabase(buf, opts)
+	c.Assert(handle.Weights, HasLen, 1)
+	value := handle.Weights["/popular"].Value
+	c.Assert(value > 10000./1025 && value < 10000./1023, Equals, true)
+	c.Assert(handle.Dirty(), Equals, false)
+}
+
+func (s *MySuite) TestAgingSurvivesReplace(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Replace([]db.Entry{{Path: "/foo", Weight: 100, UpdatedAt: time.Now().UTC().Add(-60 * 24 * time.Hour)}})
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+
+	opts := db.Options{Aging: db.Aging{HalfLife: 30 * 24 * time.Hour, Threshold: 1}}
+	handle = db.NewGobDatabase(buf, opts)
+	aged := handle.Weights["/foo"].Value
+	c.Assert(aged > 24.99 && aged < 25.01, Equals, true)
+
+	// replacing the entries with themselves, as backup restore and repair
+	// do, mustn't age them again
+	handle.Replace(handle.GetWeights())
+	buf.Reset()
+	c.Assert(handle.Save(buf), IsNil)
+	handle = db.NewGobDatabase(buf, opts)
+	value := handle.Weights["/foo"].Value
+	c.Assert(value > aged-.01 && value <= aged, Equals, true)
+}
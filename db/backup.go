@@ -0,0 +1,213 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	backupPrefix     = "db-"                       // prefix of backup file names
+	backupSuffix     = ".gob"                      // suffix of backup file names
+	backupTimeLayout = "20060102T150405.000000000" // backup ID format
+)
+
+// Backup represents a backup of the database.
+type Backup struct {
+	ID   string    // the backup ID, derived from the backup time
+	Path string    // path to the backup file
+	Time time.Time // when the backup was taken
+	Size int64     // size of the backup in bytes
+}
+
+// BackupPolicy controls how many generations of backups are retained. The
+// newest backup in each of the last Hourly hours and each of the last Daily
+// days is kept.
+type BackupPolicy struct {
+	Hourly int // number of hourly backups to keep
+	Daily  int // number of daily backups to keep
+}
+
+// Enabled returns true if any backups should be kept.
+func (p BackupPolicy) Enabled() bool {
+	return p.Hourly > 0 || p.Daily > 0
+}
+
+// interval returns the minimum time between automatic backups.
+func (p BackupPolicy) interval() time.Duration {
+	if p.Hourly > 0 {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// ListBackups returns the backups in a directory, newest first.
+func ListBackups(dir string) ([]Backup, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var backups []Backup
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+		t, err := time.Parse(backupTimeLayout, id)
+		if err != nil {
+			log.Debug().Str("name", name).Msg("ignoring unrecognized backup file")
+			continue
+		}
+		backups = append(backups, Backup{
+			ID:   id,
+			Path: filepath.Join(dir, name),
+			Time: t,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// FindBackup finds the backup with the given ID.
+func FindBackup(dir, id string) (Backup, bool) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		log.Error().Err(err).Str("dir", dir).Msg("failed to list backups")
+		return Backup{}, false
+	}
+	for _, backup := range backups {
+		if backup.ID == id {
+			return backup, true
+		}
+	}
+	return Backup{}, false
+}
+
+// RotateBackup backs up the current database file into the backup directory,
+// and then removes backups that fall outside the retention policy. A new
+// backup is only taken if the newest one is older than the policy interval,
+// unless force is set. It's a no-op if the database file doesn't exist yet.
+func RotateBackup(dbPath, dir string, policy BackupPolicy, force bool) error {
+	if !policy.Enabled() {
+		return nil
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if !force && len(backups) > 0 && now.Sub(backups[0].Time) < policy.interval() {
+		log.Debug().Str("newest", backups[0].ID).Msg("skipping backup, newest backup is recent")
+		return nil
+	}
+
+	// forced backups can be taken in quick succession, so an existing backup
+	// is never replaced; the time is bumped until the ID is unused instead
+	var dest string
+	for {
+		dest = filepath.Join(dir, backupPrefix+now.Format(backupTimeLayout)+backupSuffix)
+		err := linkOrCopy(dbPath, dest)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		now = now.Add(time.Nanosecond)
+	}
+	log.Debug().Str("path", dest).Msg("backed up database")
+
+	if backups, err = ListBackups(dir); err != nil {
+		return err
+	}
+	for _, backup := range expiredBackups(backups, policy) {
+		log.Debug().Str("path", backup.Path).Msg("removing expired backup")
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Str("path", backup.Path).Msg("failed to remove expired backup")
+		}
+	}
+	return nil
+}
+
+// expiredBackups returns the backups not retained by the policy. The input
+// must be sorted newest first.
+func expiredBackups(backups []Backup, policy BackupPolicy) []Backup {
+	hours := make(map[time.Time]bool)
+	days := make(map[string]bool)
+	var expired []Backup
+	for i, backup := range backups {
+		keep := i == 0 // always keep the newest backup
+		if hour := backup.Time.Truncate(time.Hour); !hours[hour] && len(hours) < policy.Hourly {
+			hours[hour] = true
+			keep = true
+		}
+		if day := backup.Time.Format("2006-01-02"); !days[day] && len(days) < policy.Daily {
+			days[day] = true
+			keep = true
+		}
+		if !keep {
+			expired = append(expired, backup)
+		}
+	}
+	return expired
+}
+
+// linkOrCopy hard links src to dest, falling back to copying the file. It
+// fails if dest already exists.
+func linkOrCopy(src, dest string) error {
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
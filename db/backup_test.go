@@ -0,0 +1,95 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestRotateBackup(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	dbPath := filepath.Join(baseDir, "db.gob")
+	backupDir := filepath.Join(baseDir, "backups")
+	policy := db.BackupPolicy{Hourly: 2, Daily: 3}
+
+	// nothing to back up yet
+	c.Assert(db.RotateBackup(dbPath, backupDir, policy, false), IsNil)
+	backups, err := db.ListBackups(backupDir)
+	c.Assert(err, IsNil)
+	c.Assert(backups, HasLen, 0)
+
+	// create some old backups, spread out over several days; they're
+	// relative to midnight so the days they fall on don't depend on when the
+	// test runs
+	c.Assert(os.MkdirAll(backupDir, 0700), IsNil)
+	midnight := time.Now().UTC().Truncate(24 * time.Hour)
+	ids := make(map[time.Duration]string)
+	for _, age := range []time.Duration{1 * time.Hour, 2 * time.Hour, 3 * time.Hour, 25 * time.Hour, 26 * time.Hour, 49 * time.Hour} {
+		ids[age] = midnight.Add(-age).Format("20060102T150405.000000000")
+		c.Assert(ioutil.WriteFile(filepath.Join(backupDir, "db-"+ids[age]+".gob"), nil, 0600), IsNil)
+	}
+
+	// the new backup is kept, along with the newest backup from the previous
+	// hour and the newest backups from the previous two days
+	c.Assert(ioutil.WriteFile(dbPath, []byte("data"), 0600), IsNil)
+	c.Assert(db.RotateBackup(dbPath, backupDir, policy, false), IsNil)
+	backups, err = db.ListBackups(backupDir)
+	c.Assert(err, IsNil)
+	c.Assert(backups, HasLen, 3)
+	c.Assert(backups[0].Size, Equals, int64(4))
+	c.Assert(backups[1].ID, Equals, ids[1*time.Hour])
+	c.Assert(backups[2].ID, Equals, ids[25*time.Hour])
+	_, ok := db.FindBackup(backupDir, backups[0].ID)
+	c.Assert(ok, Equals, true)
+
+	// a recent backup exists, so unforced rotation is a no-op; the database
+	// is replaced by renaming, just like jump does
+	c.Assert(ioutil.WriteFile(dbPath+".tmp", []byte("new data"), 0600), IsNil)
+	c.Assert(os.Rename(dbPath+".tmp", dbPath), IsNil)
+	c.Assert(db.RotateBackup(dbPath, backupDir, policy, false), IsNil)
+	again, err := db.ListBackups(backupDir)
+	c.Assert(err, IsNil)
+	c.Assert(again, DeepEquals, backups)
+}
+
+func (s *MySuite) TestForcedBackupsAreUnique(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	dbPath := filepath.Join(baseDir, "db.gob")
+	backupDir := filepath.Join(baseDir, "backups")
+	policy := db.BackupPolicy{Hourly: 24, Daily: 14}
+
+	// backups taken in quick succession never replace each other, even
+	// though only the newest one in the hour is kept
+	var ids []string
+	for i := 0; i < 3; i++ {
+		c.Assert(ioutil.WriteFile(dbPath, []byte("data"), 0600), IsNil)
+		c.Assert(db.RotateBackup(dbPath, backupDir, policy, true), IsNil)
+		backups, err := db.ListBackups(backupDir)
+		c.Assert(err, IsNil)
+		ids = append(ids, backups[0].ID)
+	}
+	c.Assert(ids[0], Not(Equals), ids[1])
+	c.Assert(ids[1], Not(Equals), ids[2])
+}
@@ -33,6 +33,15 @@ type Database interface {
 	// Return the list of weights in the database.
 	GetWeights() []Entry
 
+	// Merge entries into the database.
+	Import([]Entry) ImportSummary
+
+	// Return the error encountered loading the database, if any.
+	LoadError() error
+
+	// Merge re-reads the database and replays local changes on top of it.
+	Merge(io.Reader) error
+
 	// Remove a path from the database.
 	Remove(string)
 
@@ -48,6 +57,12 @@ type Database interface {
 	// Search for a query and find the best match.
 	// TODO: allow this to return multiple results.
 	Search(int, ...string) []Entry
+
+	// Explain how the candidates for a search were scored.
+	Explain(...string) []Explanation
+
+	// Search for entries matching a comparison function.
+	SearchCompare(int, string, StringCompare) []Entry
 }
 
 // NewDatabase loads a database file.
@@ -0,0 +1,43 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"time"
+)
+
+// deltaKind is the kind of change recorded in a delta.
+type deltaKind int
+
+const (
+	deltaAdjust deltaKind = iota // the weight of a path was adjusted
+	deltaRemove                  // a path was removed
+	deltaImport                  // an entry was imported
+)
+
+// delta is a single change applied to a database since it was loaded. Deltas
+// are replayed on top of the on-disk database at save time, so concurrent
+// writers compose rather than overwrite each other.
+type delta struct {
+	kind     deltaKind // the kind of change
+	path     string    // the path that was changed
+	weight   float64   // the weight adjustment, for deltaAdjust
+	repo     string    // the repository root of the path, for deltaAdjust
+	pathKind Kind      // the kind of path, for deltaAdjust
+	at       time.Time // when the change was made
+	entry    Entry     // the imported entry, for deltaImport
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestMergeDeltas(c *C) {
+	base := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	base.AdjustWeight("/foo", 1)
+	base.AdjustWeight("/bar", 1)
+	saved := new(bytes.Buffer)
+	c.Assert(base.Save(saved), IsNil)
+
+	// two writers load the same database
+	first := db.NewGobDatabase(bytes.NewReader(saved.Bytes()), db.Options{})
+	second := db.NewGobDatabase(bytes.NewReader(saved.Bytes()), db.Options{})
+
+	// the first writer adds an entry and saves
+	first.AdjustWeight("/baz", 1)
+	firstSaved := new(bytes.Buffer)
+	c.Assert(first.Save(firstSaved), IsNil)
+
+	// the second writer removes an entry, bumps another, and merges
+	second.Remove("/bar")
+	second.AdjustWeight("/foo", 1)
+	c.Assert(second.Merge(firstSaved), IsNil)
+	c.Assert(second.Weights, HasLen, 2)
+	c.Assert(second.Weights["/baz"].Value, Equals, 1.)
+	c.Assert(second.Weights["/foo"].Value > 1, Equals, true)
+	_, ok := second.Weights["/bar"]
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MySuite) TestMergeAfterReplace(c *C) {
+	other := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	other.AdjustWeight("/foo", 1)
+	saved := new(bytes.Buffer)
+	c.Assert(other.Save(saved), IsNil)
+
+	// replaced weights win over the on-disk database
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Replace([]db.Entry{{Path: "/bar", Weight: 1}})
+	c.Assert(handle.Merge(saved), IsNil)
+	c.Assert(handle.Weights, HasLen, 1)
+	c.Assert(handle.Weights["/bar"].Value, Equals, 1.)
+}
+
+func (s *MySuite) TestMergeKeepsRepo(c *C) {
+	repo := s.createTempDir(c)
+	defer os.RemoveAll(repo)
+	c.Assert(os.Mkdir(filepath.Join(repo, ".git"), 0755), IsNil)
+
+	// the repository is looked up when the path is visited, not again when
+	// the change is replayed
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(repo, 1)
+	c.Assert(os.Remove(filepath.Join(repo, ".git")), IsNil)
+	c.Assert(handle.Merge(strings.NewReader("")), IsNil)
+	c.Assert(handle.Weights[repo].Repo, Equals, repo)
+}
+
+func (s *MySuite) TestMergeKeepsKind(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	file := filepath.Join(baseDir, "file")
+	c.Assert(ioutil.WriteFile(file, []byte("hello"), 0644), IsNil)
+
+	// the kind is looked up when the path is visited, not again when the
+	// change is replayed
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(file, 1)
+	c.Assert(os.Remove(file), IsNil)
+	c.Assert(os.Mkdir(file, 0755), IsNil)
+	c.Assert(handle.Merge(strings.NewReader("")), IsNil)
+	c.Assert(handle.Weights[file].Kind, Equals, db.KindFile)
+}
@@ -53,11 +53,8 @@ func Dump(d Database, opts DumpOpts) interface{} {
 		}
 	}
 
-	output := struct {
-		Format  string  `json:"format"`
-		Weights []Entry `json:"weights"`
-	}{
-		Format:  "gob",
+	output := jsonExport{
+		Version: exportVersion,
 		Weights: weights,
 	}
 	sort.Sort(descendingWeight(output.Weights))
@@ -16,13 +16,94 @@
 
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Entry represents a database entry.
 type Entry struct {
 	Path      string    `json:"path"`
 	Weight    float64   `json:"weight"`
 	UpdatedAt time.Time `json:"time,string"`
+	Repo      string    `json:"repo,omitempty"`
+	Kind      Kind      `json:"kind,omitempty"`
+
+	Visits    int         `json:"visits,omitempty"`
+	FirstSeen time.Time   `json:"firstSeen"`
+	Recent    []time.Time `json:"recent,omitempty"`
+	Daily     []DayVisits `json:"daily,omitempty"`
+
+	AgedAt time.Time `json:"agedAt"`
+}
+
+// MarshalJSON encodes the entry as JSON, leaving out the first seen and aged
+// times of entries that don't have them rather than printing the zero time.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	return json.Marshal(struct {
+		entry
+		FirstSeen *time.Time `json:"firstSeen,omitempty"`
+		AgedAt    *time.Time `json:"agedAt,omitempty"`
+	}{
+		entry:     entry(e),
+		FirstSeen: optionalTime(e.FirstSeen),
+		AgedAt:    optionalTime(e.AgedAt),
+	})
+}
+
+// optionalTime returns a pointer to t, or nil if t is the zero time.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// newEntry creates an entry from a path and its weight.
+func newEntry(path string, weight Weight) Entry {
+	return Entry{
+		Path:      path,
+		Weight:    weight.Value,
+		UpdatedAt: weight.UpdatedAt,
+		Repo:      weight.Repo,
+		Kind:      weight.Kind,
+		Visits:    weight.Visits,
+		FirstSeen: weight.FirstSeen,
+		Recent:    weight.RecentVisits(),
+		Daily:     weight.DailyVisits(),
+		AgedAt:    weight.AgedAt,
+	}
+}
+
+// LastVisit returns the time the entry was last visited. This is its most
+// recent visit rather than its update time, since lowering a weight updates the
+// entry without visiting it.
+func (e Entry) LastVisit() time.Time {
+	if len(e.Recent) == 0 {
+		return e.UpdatedAt
+	}
+	return e.Recent[len(e.Recent)-1]
+}
+
+// weight returns the weight stored in the database for the entry. Entries
+// without visit statistics are assumed to have been visited once, and entries
+// without daily visit counts have them counted from their recent visits.
+func (e Entry) weight() Weight {
+	w := Weight{
+		Value:     e.Weight,
+		UpdatedAt: e.UpdatedAt,
+		Repo:      e.Repo,
+		Kind:      e.Kind,
+		Visits:    e.Visits,
+		FirstSeen: e.FirstSeen,
+		AgedAt:    e.AgedAt,
+	}
+	w.setRecentVisits(e.Recent)
+	w.setDailyVisits(e.Daily)
+	w.backfillVisits()
+	w.backfillDaily()
+	return w
 }
 
 type descendingWeight []Entry
@@ -50,11 +131,7 @@ func (a ascendingWeight) Less(i, j int) bool {
 func toEntryList(w weightMap) []Entry {
 	var entries []Entry
 	for path, weight := range w {
-		entries = append(entries, Entry{
-			Path:      path,
-			Weight:    weight.Value,
-			UpdatedAt: weight.UpdatedAt,
-		})
+		entries = append(entries, newEntry(path, weight))
 	}
 	return entries
 }
@@ -0,0 +1,81 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"sort"
+)
+
+// PassMatch records a search pass matching a candidate.
+type PassMatch struct {
+	Pass  string  `json:"pass"`  // the name of the pass
+	Alpha float64 `json:"alpha"` // the alpha of the pass
+	Score float64 `json:"score"` // the match score, in (0, 1]
+}
+
+// Explanation explains how a search candidate was scored.
+type Explanation struct {
+	Path      string      `json:"path"`      // the candidate path
+	Weight    float64     `json:"weight"`    // the weight in the database
+	Beta      float64     `json:"beta"`      // the time decay factor applied by the scorer
+	Passes    []PassMatch `json:"passes"`    // the passes that matched, in order
+	Proximity float64     `json:"proximity"` // the boost for being near the current directory
+	Score     float64     `json:"score"`     // the final score
+	Rejected  string      `json:"rejected"`  // why the candidate was rejected, if it was
+}
+
+// EnableExplain makes the searcher record how each candidate is scored.
+func (s *Searcher) EnableExplain() {
+	s.explain = make(map[string]*Explanation)
+}
+
+// explainMatch records a pass matching path, if explain mode is enabled.
+func (s *Searcher) explainMatch(path string, input Weight, pass Pass, score float64) {
+	if s.explain == nil {
+		return
+	}
+	e, ok := s.explain[path]
+	if !ok {
+		e = &Explanation{Path: path, Weight: input.Value, Proximity: 1}
+		if input.Value != 0 {
+			e.Beta = s.scorer.Score(path, input) / input.Value
+		}
+		s.explain[path] = e
+	}
+	e.Passes = append(e.Passes, PassMatch{Pass: pass.Name, Alpha: pass.Alpha, Score: score})
+}
+
+// Explanations returns explanations for every search candidate, best first.
+// Candidates that Best would reject are included, with the reason they were
+// rejected. EnableExplain must be called before running any passes.
+func (s *Searcher) Explanations() []Explanation {
+	var explanations []Explanation
+	for path, e := range s.explain {
+		e.Score = s.output[path].Value
+		if err := CheckKind(path, s.output[path].Kind); err != nil {
+			e.Rejected = err.Error()
+		}
+		explanations = append(explanations, *e)
+	}
+	sort.Slice(explanations, func(i, j int) bool {
+		if explanations[i].Score == explanations[j].Score {
+			return explanations[i].Path < explanations[j].Path
+		}
+		return explanations[i].Score > explanations[j].Score
+	})
+	return explanations
+}
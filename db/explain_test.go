@@ -0,0 +1,61 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestExplain(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	foo := filepath.Join(baseDir, "foo")
+	c.Assert(os.MkdirAll(foo, 0755), IsNil)
+	missing := filepath.Join(baseDir, "foobar")
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(foo, 2)
+	handle.AdjustWeight(missing, 1)
+
+	explanations := handle.Explain("foo")
+	c.Assert(explanations, HasLen, 2)
+
+	e := explanations[0]
+	c.Assert(e.Path, Equals, foo)
+	c.Assert(e.Weight, Equals, 2.)
+	c.Assert(e.Beta, Equals, 1.)
+	c.Assert(e.Score, Equals, 2*10*2.5*1.)
+	c.Assert(e.Rejected, Equals, "")
+	var passes []string
+	for _, p := range e.Passes {
+		passes = append(passes, p.Pass)
+	}
+	c.Assert(passes, DeepEquals, []string{"exact-suffix", "suffix", "contains"})
+
+	e = explanations[1]
+	c.Assert(e.Path, Equals, missing)
+	c.Assert(e.Passes, HasLen, 1)
+	c.Assert(e.Rejected, Not(Equals), "")
+
+	// explaining doesn't remove rejected paths
+	c.Assert(handle.Weights, HasLen, 2)
+}
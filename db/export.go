@@ -0,0 +1,233 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportVersion is the version of the JSON export format.
+const exportVersion = 1
+
+// ExportFormats lists the supported export formats.
+var ExportFormats = []string{"autojump", "csv", "json", "tsv"}
+
+// exportHeader is the header row written to CSV and TSV exports. Only the
+// first three columns are required when loading, since older exports don't
+// have the others.
+var exportHeader = []string{"path", "weight", "time", "kind", "visits", "firstSeen", "recent", "daily", "agedAt", "repo"}
+
+// exportDayLayout is the layout of days in the daily column of CSV and TSV
+// exports.
+const exportDayLayout = "2006-01-02"
+
+// jsonExport is the JSON representation of the database.
+type jsonExport struct {
+	Version int     `json:"version"`
+	Weights []Entry `json:"weights"`
+}
+
+// Export writes entries to w in the given format, highest weights first.
+func Export(w io.Writer, entries []Entry, format string) error {
+	sorted := append([]Entry(nil), entries...)
+	sort.Sort(descendingWeight(sorted))
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(jsonExport{Version: exportVersion, Weights: sorted})
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		if err := cw.Write(exportHeader); err != nil {
+			return err
+		}
+		for _, entry := range sorted {
+			record := []string{
+				entry.Path,
+				strconv.FormatFloat(entry.Weight, 'g', -1, 64),
+				entry.UpdatedAt.Format(time.RFC3339Nano),
+				entry.Kind.String(),
+				strconv.Itoa(entry.Visits),
+				formatExportTime(entry.FirstSeen),
+				formatExportTimes(entry.Recent),
+				formatExportDaily(entry.Daily),
+				formatExportTime(entry.AgedAt),
+				entry.Repo,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "autojump":
+		for _, entry := range sorted {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", strconv.FormatFloat(entry.Weight, 'f', -1, 64), entry.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// LoadExport loads entries written by Export in the given format.
+func LoadExport(r io.Reader, format string) ([]Entry, error) {
+	switch format {
+	case "json":
+		var export jsonExport
+		if err := json.NewDecoder(r).Decode(&export); err != nil {
+			return nil, err
+		}
+		if export.Version > exportVersion {
+			return nil, fmt.Errorf("unsupported export version %d", export.Version)
+		}
+		return export.Weights, nil
+	case "csv", "tsv":
+		cr := csv.NewReader(r)
+		if format == "tsv" {
+			cr.Comma = '\t'
+		}
+		cr.FieldsPerRecord = -1
+		records, err := cr.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 && records[0][0] == exportHeader[0] {
+			records = records[1:]
+		}
+		var entries []Entry
+		for i, record := range records {
+			if len(record) < 3 || len(record) > len(exportHeader) {
+				return nil, fmt.Errorf("record %d: wrong number of fields", i+1)
+			}
+			entry, err := parseExportRecord(record)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", i+1, err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	case "autojump":
+		return LoadAutojumpDatabase(r)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// parseExportRecord parses a CSV or TSV export record, which has at least the
+// path, weight, and time columns.
+func parseExportRecord(record []string) (Entry, error) {
+	// pad missing optional columns, which are empty when not set
+	record = append(record, make([]string, len(exportHeader)-len(record))...)
+
+	var entry Entry
+	var err error
+	entry.Path = record[0]
+	if entry.Weight, err = strconv.ParseFloat(record[1], 64); err != nil {
+		return entry, fmt.Errorf("invalid weight: %w", err)
+	}
+	if entry.UpdatedAt, err = time.Parse(time.RFC3339Nano, record[2]); err != nil {
+		return entry, fmt.Errorf("invalid time: %w", err)
+	}
+	if record[3] != "" {
+		if entry.Kind, err = ParseKind(record[3]); err != nil {
+			return entry, err
+		}
+	}
+	if record[4] != "" {
+		if entry.Visits, err = strconv.Atoi(record[4]); err != nil {
+			return entry, fmt.Errorf("invalid visits: %w", err)
+		}
+	}
+	if entry.FirstSeen, err = parseExportTime(record[5]); err != nil {
+		return entry, fmt.Errorf("invalid first seen time: %w", err)
+	}
+	for _, field := range strings.Fields(record[6]) {
+		at, err := time.Parse(time.RFC3339Nano, field)
+		if err != nil {
+			return entry, fmt.Errorf("invalid recent visit: %w", err)
+		}
+		entry.Recent = append(entry.Recent, at)
+	}
+	for _, field := range strings.Fields(record[7]) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return entry, fmt.Errorf("invalid daily visits %q", field)
+		}
+		day, err := time.ParseInLocation(exportDayLayout, parts[0], time.Local)
+		if err != nil {
+			return entry, fmt.Errorf("invalid daily visits: %w", err)
+		}
+		visits, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return entry, fmt.Errorf("invalid daily visits: %w", err)
+		}
+		entry.Daily = append(entry.Daily, DayVisits{Day: day, Visits: visits})
+	}
+	if entry.AgedAt, err = parseExportTime(record[8]); err != nil {
+		return entry, fmt.Errorf("invalid aged time: %w", err)
+	}
+	entry.Repo = record[9]
+	return entry, nil
+}
+
+// formatExportTime formats an optional time for CSV and TSV exports, leaving
+// zero times empty.
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseExportTime parses a time formatted by formatExportTime.
+func parseExportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// formatExportTimes formats a list of times for CSV and TSV exports,
+// separated by spaces.
+func formatExportTimes(times []time.Time) string {
+	var fields []string
+	for _, t := range times {
+		fields = append(fields, t.Format(time.RFC3339Nano))
+	}
+	return strings.Join(fields, " ")
+}
+
+// formatExportDaily formats daily visit counts for CSV and TSV exports, as
+// space separated day=visits pairs.
+func formatExportDaily(daily []DayVisits) string {
+	var fields []string
+	for _, v := range daily {
+		fields = append(fields, v.Day.Format(exportDayLayout)+"="+strconv.Itoa(v.Visits))
+	}
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestExportRoundTrip(c *C) {
+	now := time.Now().UTC()
+	entries := []db.Entry{
+		{Path: "/foo", Weight: 2.5, UpdatedAt: now},
+		{Path: "/with,comma\tand tab", Weight: 1, UpdatedAt: now.Add(-time.Hour), Kind: db.KindFile},
+		{
+			Path:      "/repo/visited",
+			Weight:    0.5,
+			UpdatedAt: now.Add(-2 * time.Hour),
+			Repo:      "/repo",
+			Visits:    12,
+			FirstSeen: now.Add(-48 * time.Hour),
+			Recent:    []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)},
+			Daily:     []db.DayVisits{{Day: time.Date(2019, time.October, 1, 0, 0, 0, 0, time.Local), Visits: 10}},
+			AgedAt:    now.Add(-time.Minute),
+		},
+	}
+	for _, format := range []string{"json", "csv", "tsv"} {
+		buf := new(bytes.Buffer)
+		c.Assert(db.Export(buf, entries, format), IsNil)
+		loaded, err := db.LoadExport(buf, format)
+		c.Assert(err, IsNil)
+		c.Assert(loaded, HasLen, 3)
+		for i := range entries {
+			c.Assert(loaded[i].Path, Equals, entries[i].Path)
+			c.Assert(loaded[i].Weight, Equals, entries[i].Weight)
+			c.Assert(loaded[i].UpdatedAt.Equal(entries[i].UpdatedAt), Equals, true)
+			c.Assert(loaded[i].Kind, Equals, entries[i].Kind)
+			c.Assert(loaded[i].Repo, Equals, entries[i].Repo)
+			c.Assert(loaded[i].Visits, Equals, entries[i].Visits)
+			c.Assert(loaded[i].FirstSeen.Equal(entries[i].FirstSeen), Equals, true)
+			c.Assert(loaded[i].Recent, HasLen, len(entries[i].Recent))
+			for j := range entries[i].Recent {
+				c.Assert(loaded[i].Recent[j].Equal(entries[i].Recent[j]), Equals, true)
+			}
+			c.Assert(loaded[i].AgedAt.Equal(entries[i].AgedAt), Equals, true)
+			c.Assert(loaded[i].Daily, HasLen, len(entries[i].Daily))
+			for j := range entries[i].Daily {
+				c.Assert(loaded[i].Daily[j].Day.Equal(entries[i].Daily[j].Day), Equals, true)
+				c.Assert(loaded[i].Daily[j].Visits, Equals, entries[i].Daily[j].Visits)
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	c.Assert(db.Export(buf, entries[:1], "autojump"), IsNil)
+	c.Assert(buf.String(), Equals, "2.5\t/foo\n")
+
+	c.Assert(db.Export(buf, entries, "xml"), Not(IsNil))
+	_, err := db.LoadExport(strings.NewReader(""), "xml")
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *MySuite) TestEntryJSONOmitsZeroTimes(c *C) {
+	now := time.Date(2019, time.October, 1, 12, 0, 0, 0, time.UTC)
+	out, err := json.Marshal(db.Entry{Path: "/foo", Weight: 1, UpdatedAt: now})
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(out), "firstSeen"), Equals, false)
+	c.Assert(strings.Contains(string(out), "agedAt"), Equals, false)
+	c.Assert(strings.Contains(string(out), "0001-01-01"), Equals, false)
+
+	out, err = json.Marshal(db.Entry{Path: "/foo", Weight: 1, UpdatedAt: now, FirstSeen: now, AgedAt: now})
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(out), `"firstSeen":"2019-10-01T12:00:00Z"`), Equals, true)
+	c.Assert(strings.Contains(string(out), `"agedAt":"2019-10-01T12:00:00Z"`), Equals, true)
+}
+
+func (s *MySuite) TestLoadExportWithoutKind(c *C) {
+	loaded, err := db.LoadExport(strings.NewReader("path,weight,time\n/foo,1,2019-10-01T00:00:00Z\n"), "csv")
+	c.Assert(err, IsNil)
+	c.Assert(loaded, HasLen, 1)
+	c.Assert(loaded[0].Kind, Equals, db.KindDir)
+
+	_, err = db.LoadExport(strings.NewReader("/foo,1,2019-10-01T00:00:00Z,socket\n"), "csv")
+	c.Assert(err, Not(IsNil))
+
+	_, err = db.LoadExport(strings.NewReader("/foo,1,2019-10-01T00:00:00Z,dir,many\n"), "csv")
+	c.Assert(err, Not(IsNil))
+
+	_, err = db.LoadExport(strings.NewReader("/foo,1,2019-10-01T00:00:00Z,dir,1,,,2019-10-01\n"), "csv")
+	c.Assert(err, Not(IsNil))
+}
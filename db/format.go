@@ -0,0 +1,204 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The on-disk database starts with a fixed size header, followed by a gob
+// encoded payload. Databases written before the header was introduced are a
+// bare gob encoded weightMap, which we treat as version 0.
+const (
+	formatMagic   = "JMPD" // magic number identifying a jump database
+	formatVersion = 3      // the current format version
+)
+
+var (
+	// ErrChecksum is returned when the database payload is corrupt.
+	ErrChecksum = errors.New("database checksum mismatch")
+
+	// ErrUnsupportedVersion is returned when the database was written by a
+	// newer version of jump.
+	ErrUnsupportedVersion = errors.New("unsupported database version")
+)
+
+// formatHeader is the header written before the database payload.
+type formatHeader struct {
+	Magic    [4]byte // always formatMagic
+	Version  uint32  // format version of the payload
+	Length   uint64  // length of the payload in bytes
+	Checksum uint32  // CRC-32 (IEEE) of the payload
+}
+
+// migration upgrades a payload from one format version to the next.
+type migration func([]byte) ([]byte, error)
+
+// migrations maps a format version to the migration upgrading it to the next
+// version.
+var migrations = make(map[uint32]migration)
+
+// registerMigration registers the migration upgrading payloads from the given
+// version.
+func registerMigration(from uint32, m migration) {
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("duplicate migration for database version %d", from))
+	}
+	migrations[from] = m
+}
+
+func init() {
+	// version 1 added the header, but kept the payload unchanged
+	registerMigration(0, func(payload []byte) ([]byte, error) { return payload, nil })
+
+	// version 2 added visit statistics, which are backfilled from the
+	// last update time
+	registerMigration(1, backfillMigration((*Weight).backfillVisits))
+
+	// version 3 added daily visit counts, which are backfilled from the
+	// recent visits
+	registerMigration(2, backfillMigration((*Weight).backfillDaily))
+}
+
+// backfillMigration returns a migration that calls backfill on every weight.
+func backfillMigration(backfill func(*Weight)) migration {
+	return func(payload []byte) ([]byte, error) {
+		weights := make(weightMap)
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&weights); err != nil {
+			return nil, err
+		}
+		for path, w := range weights {
+			backfill(&w)
+			weights[path] = w
+		}
+		out := new(bytes.Buffer)
+		if err := gob.NewEncoder(out).Encode(weights); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+}
+
+// migratePayload upgrades a payload to the current format version.
+func migratePayload(version uint32, payload []byte) ([]byte, error) {
+	if version > formatVersion {
+		return nil, fmt.Errorf("%w: %d (newest supported is %d)", ErrUnsupportedVersion, version, formatVersion)
+	}
+	for ; version < formatVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from database version %d", version)
+		}
+		var err error
+		if payload, err = m(payload); err != nil {
+			return nil, fmt.Errorf("migrating database version %d: %w", version, err)
+		}
+		log.Debug().Uint32("from", version).Uint32("to", version+1).Msg("migrated database")
+	}
+	return payload, nil
+}
+
+// readPayload reads the payload and its format version. An empty input yields
+// an empty payload at the current version.
+func readPayload(r io.Reader) (uint32, []byte, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(formatMagic))
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	if len(magic) == 0 {
+		return formatVersion, nil, nil
+	}
+	if string(magic) != formatMagic {
+		// a legacy database without a header
+		payload, err := ioutil.ReadAll(br)
+		return 0, payload, err
+	}
+
+	var hdr formatHeader
+	if err := binary.Read(br, binary.BigEndian, &hdr); err != nil {
+		return 0, nil, fmt.Errorf("reading database header: %w", err)
+	}
+
+	// the header may be corrupt, so don't trust the length to size a buffer
+	length := int64(math.MaxInt64)
+	if hdr.Length < uint64(length) {
+		length = int64(hdr.Length)
+	}
+	payload, err := ioutil.ReadAll(io.LimitReader(br, length))
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading database payload: %w", err)
+	}
+	if uint64(len(payload)) != hdr.Length {
+		return 0, nil, fmt.Errorf("reading database payload: %w", io.ErrUnexpectedEOF)
+	}
+	if crc32.ChecksumIEEE(payload) != hdr.Checksum {
+		return 0, nil, ErrChecksum
+	}
+	return hdr.Version, payload, nil
+}
+
+// decodeWeights decodes a weight map, migrating it from older format versions
+// as necessary. The format version the input was written in is also returned.
+// An empty input is treated as an empty database.
+func decodeWeights(r io.Reader) (weightMap, uint32, error) {
+	version, payload, err := readPayload(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	weights := make(weightMap)
+	if len(payload) == 0 {
+		return weights, version, nil
+	}
+	if payload, err = migratePayload(version, payload); err != nil {
+		return nil, 0, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&weights); err != nil {
+		return nil, 0, err
+	}
+	return weights, version, nil
+}
+
+// encodeWeights encodes a weight map with the current format header.
+func encodeWeights(w io.Writer, weights weightMap) error {
+	payload := new(bytes.Buffer)
+	if err := gob.NewEncoder(payload).Encode(weights); err != nil {
+		return err
+	}
+	hdr := formatHeader{
+		Version:  formatVersion,
+		Length:   uint64(payload.Len()),
+		Checksum: crc32.ChecksumIEEE(payload.Bytes()),
+	}
+	copy(hdr.Magic[:], formatMagic)
+	if err := binary.Write(w, binary.BigEndian, &hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(payload.Bytes())
+	return err
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"hash/crc32"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFormatRoundTrip(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight("/foo", 1)
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+	c.Assert(strings.HasPrefix(buf.String(), "JMPD"), Equals, true)
+
+	handle = db.NewGobDatabase(buf, db.Options{})
+	c.Assert(handle.Weights, HasLen, 1)
+	c.Assert(handle.Dirty(), Equals, false)
+}
+
+func (s *MySuite) TestFormatLegacyUpgrade(c *C) {
+	// databases written before the header was added are a bare weight map
+	buf := new(bytes.Buffer)
+	now := time.Now().UTC()
+	legacy := map[string]db.Weight{"/foo": {Value: 1, UpdatedAt: now}}
+	c.Assert(gob.NewEncoder(buf).Encode(legacy), IsNil)
+
+	handle := db.NewGobDatabase(buf, db.Options{})
+	c.Assert(handle.Weights, HasLen, 1)
+	c.Assert(handle.Dirty(), Equals, true)
+
+	// visit statistics are backfilled from the last update
+	w := handle.Weights["/foo"]
+	c.Assert(w.Visits, Equals, 1)
+	c.Assert(w.FirstSeen.Equal(now), Equals, true)
+	c.Assert(w.RecentVisits(), HasLen, 1)
+	c.Assert(w.DailyVisits(), HasLen, 1)
+}
+
+func (s *MySuite) TestFormatChecksum(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight("/foo", 1)
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+
+	// flip a bit in the payload
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0x1
+	handle = db.NewGobDatabase(bytes.NewReader(data), db.Options{})
+	c.Assert(handle.Weights, HasLen, 0)
+}
+
+func (s *MySuite) TestFormatCorruptLength(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight("/foo", 1)
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+
+	// the payload length is at bytes 8-15 of the header
+	for _, length := range []uint64{1 << 62, 1 << 40, uint64(buf.Len())} {
+		data := append([]byte(nil), buf.Bytes()...)
+		binary.BigEndian.PutUint64(data[8:16], length)
+		handle = db.NewGobDatabase(bytes.NewReader(data), db.Options{})
+		c.Assert(handle.LoadError(), Not(IsNil))
+		c.Assert(handle.Weights, HasLen, 0)
+	}
+}
+
+func (s *MySuite) TestFormatUpgradeDailyVisits(c *C) {
+	// version 2 databases have recent visits, but no daily visit counts
+	now := time.Now()
+	w := db.Weight{Value: 1, UpdatedAt: now.UTC(), Visits: 3}
+	w.Recent[0] = now.AddDate(0, 0, -1).UTC()
+	w.Recent[1] = now.UTC()
+	w.Recent[2] = now.UTC()
+	payload := new(bytes.Buffer)
+	c.Assert(gob.NewEncoder(payload).Encode(map[string]db.Weight{"/foo": w}), IsNil)
+
+	buf := new(bytes.Buffer)
+	header := struct {
+		Magic    [4]byte
+		Version  uint32
+		Length   uint64
+		Checksum uint32
+	}{[4]byte{'J', 'M', 'P', 'D'}, 2, uint64(payload.Len()), crc32.ChecksumIEEE(payload.Bytes())}
+	c.Assert(binary.Write(buf, binary.BigEndian, header), IsNil)
+	buf.Write(payload.Bytes())
+
+	handle := db.NewGobDatabase(buf, db.Options{})
+	c.Assert(handle.LoadError(), IsNil)
+	c.Assert(handle.Dirty(), Equals, true)
+
+	// the daily visit counts are backfilled from the recent visits
+	daily := handle.Weights["/foo"].DailyVisits()
+	c.Assert(daily, HasLen, 2)
+	c.Assert(daily[0].Visits, Equals, 1)
+	c.Assert(daily[1].Visits, Equals, 2)
+}
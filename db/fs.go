@@ -26,6 +26,9 @@ import (
 // ErrNotDir is returned by CheckIsDir when the path is not a directory.
 var ErrNotDir = errors.New("path is not a directory")
 
+// ErrNotFile is returned by CheckIsFile when the path is not a regular file.
+var ErrNotFile = errors.New("path is not a regular file")
+
 // CheckIsDir checks that the input path is a directory.
 func CheckIsDir(path string) error {
 	st, err := os.Stat(path)
@@ -39,3 +42,34 @@ func CheckIsDir(path string) error {
 	}
 	return nil
 }
+
+// CheckIsFile checks that the input path is a regular file.
+func CheckIsFile(path string) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("failed to stat path")
+		return err
+	}
+	if !st.Mode().IsRegular() {
+		log.Warn().Str("path", path).Msg("specified file is not a regular file")
+		return ErrNotFile
+	}
+	return nil
+}
+
+// CheckKind checks that the input path is of the given kind.
+func CheckKind(path string, kind Kind) error {
+	if kind == KindFile {
+		return CheckIsFile(path)
+	}
+	return CheckIsDir(path)
+}
+
+// pathKind returns the kind of path, assuming a directory if it can't be
+// determined.
+func pathKind(path string) Kind {
+	if st, err := os.Stat(path); err == nil && st.Mode().IsRegular() {
+		return KindFile
+	}
+	return KindDir
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+// Scoring constants for fuzzy matching. Every matched character scores
+// fuzzyMatch, with bonuses for matches at the start of a path component (or
+// word within a component) and for runs of consecutive matches.
+const (
+	fuzzyMatch       = 1.
+	fuzzyBoundary    = 2.
+	fuzzyConsecutive = 1.
+	fuzzyMaxPerChar  = fuzzyMatch + fuzzyBoundary + fuzzyConsecutive
+)
+
+// isBoundary checks if the character at index i starts a path component or a
+// word within one.
+func isBoundary(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch s[i-1] {
+	case '/', '-', '_', '.', ' ':
+		return true
+	}
+	return false
+}
+
+// FuzzyScore scores how well needle matches path as a subsequence, in the
+// style of fzf. The result is in the range (0, 1], or 0 if needle isn't a
+// subsequence of path. Matching is done from the end of the path, so that
+// matches in the final path components are preferred.
+func FuzzyScore(path, needle string) float64 {
+	if needle == "" {
+		return 0
+	}
+	score := 0.
+	prev := -1 // index of the previously matched character
+	j := len(needle) - 1
+	for i := len(path) - 1; i >= 0 && j >= 0; i-- {
+		if path[i] != needle[j] {
+			continue
+		}
+		score += fuzzyMatch
+		if isBoundary(path, i) {
+			score += fuzzyBoundary
+		}
+		if prev == i+1 {
+			score += fuzzyConsecutive
+		}
+		prev = i
+		j--
+	}
+	if j >= 0 {
+		return 0
+	}
+	return score / (fuzzyMaxPerChar * float64(len(needle)))
+}
+
+// FuzzyMatch is a StringCompare that checks if needle is a subsequence of
+// path.
+func FuzzyMatch(path, needle string) bool {
+	return FuzzyScore(path, needle) > 0
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFuzzyScore(c *C) {
+	c.Assert(db.FuzzyScore("/home/me/projects/jump/db", "prjmd"), Not(Equals), 0.)
+	c.Assert(db.FuzzyScore("/home/me/projects/jump/db", "xyz"), Equals, 0.)
+	c.Assert(db.FuzzyScore("/home/me/projects/jump/db", ""), Equals, 0.)
+	c.Assert(db.FuzzyScore("/db", "/db") <= 1, Equals, true)
+
+	// component boundaries and consecutive runs score higher
+	c.Assert(db.FuzzyScore("/src/jump", "jump") > db.FuzzyScore("/src/xjxuxmxp", "jump"), Equals, true)
+	c.Assert(db.FuzzyScore("/a/foo-bar", "fb") > db.FuzzyScore("/a/oxfxbx", "fb"), Equals, true)
+}
+
+func (s *MySuite) TestFuzzySearch(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	target := filepath.Join(baseDir, "projects", "jump", "db")
+	other := filepath.Join(baseDir, "pmd")
+	c.Assert(os.MkdirAll(target, 0755), IsNil)
+	c.Assert(os.MkdirAll(other, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(target, 1)
+	handle.AdjustWeight(other, 1)
+	c.Assert(handle.Search(1, "prjmd"), HasLen, 0)
+
+	handle = db.NewGobDatabase(strings.NewReader(""), db.Options{Fuzzy: true})
+	handle.AdjustWeight(target, 1)
+	handle.AdjustWeight(other, 100)
+	entries := handle.Search(2, "prjmd")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, target)
+
+	// fuzzy matching isn't tried when there are regular matches, even
+	// if the fuzzy match has a much higher weight
+	handle = db.NewGobDatabase(strings.NewReader(""), db.Options{Fuzzy: true})
+	handle.AdjustWeight(target, 100)
+	handle.AdjustWeight(other, 1)
+	entries = handle.Search(2, "pmd")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, other)
+}
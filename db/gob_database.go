@@ -17,45 +17,62 @@
 package db
 
 import (
-	"encoding/gob"
 	"io"
 	"math"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 // GobDatabase represents the database.
 type GobDatabase struct {
-	dirty   bool      // dirty bit
-	opts    Options   // database options
-	Weights weightMap // map of entry to weight
+	dirty    bool      // dirty bit
+	replaced bool      // weights were replaced wholesale, don't merge
+	deltas   []delta   // changes applied since the database was loaded
+	loadErr  error     // error decoding the database, if any
+	opts     Options   // database options
+	Weights  weightMap // map of entry to weight
 }
 
 // AdjustWeight adjusts the weight of a path. The adjusted weight value is
 // returned.
 func (d *GobDatabase) AdjustWeight(path string, weight float64) {
 	d.dirty = true
-
-	var newWeight float64
+	change := delta{kind: deltaAdjust, path: path, weight: weight, at: time.Now().UTC()}
 	if weight >= 0 {
-		// increase the weight
-		current := d.Weights[path].Value
-		d.Weights[path] = NewWeight(math.Sqrt(current*current + weight*weight))
+		// look up the path metadata once, rather than every time the
+		// change is replayed
+		change.repo = FindRepoRoot(path)
+		change.pathKind = pathKind(path)
+	}
+	d.deltas = append(d.deltas, change)
+	d.adjustWeight(change)
+}
+
+// adjustWeight implements AdjustWeight without recording a delta.
+func (d *GobDatabase) adjustWeight(change delta) {
+	current := d.Weights[change.path]
+	current.UpdatedAt = change.at
+	if change.weight >= 0 {
+		// increase the weight, refreshing the path metadata
+		current.Value = d.opts.ranker().Adjust(current.Value, change.weight)
+		current.Repo = change.repo
+		current.Kind = change.pathKind
+		current.visit(change.at)
+		d.Weights[change.path] = current
 		return
 	}
 
 	// decrease the weight
-	newWeight = d.Weights[path].Value + weight
-	if newWeight <= 0 {
+	current.Value += change.weight
+	if current.Value <= 0 {
 		// if the weight is negative or zero, delete it
-		d.Remove(path)
+		delete(d.Weights, change.path)
 		return
 	}
-	d.Weights[path] = NewWeight(newWeight)
+	d.Weights[change.path] = current
 }
 
 // Dirty checks the dirty bit.
@@ -66,31 +83,55 @@ func (d *GobDatabase) Dirty() bool {
 // Remove removes a path from the database.
 func (d *GobDatabase) Remove(path string) {
 	d.dirty = true
+	d.deltas = append(d.deltas, delta{kind: deltaRemove, path: path, at: time.Now().UTC()})
 	delete(d.Weights, path)
 }
 
+// Merge re-reads the database from r and replays the changes made to this
+// database since it was loaded on top of it. If the weights were replaced
+// wholesale the on-disk contents are ignored.
+func (d *GobDatabase) Merge(r io.Reader) error {
+	if d.loadErr != nil {
+		return ErrReadOnly
+	}
+	if d.replaced {
+		log.Debug().Msg("weights were replaced, skipping merge")
+		return nil
+	}
+	weights, _, err := decodeWeights(r)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to decode weights for merge")
+		return err
+	}
+	d.Weights = weights
+	d.opts.Aging.Apply(d.Weights, time.Now().UTC())
+	for _, change := range d.deltas {
+		switch change.kind {
+		case deltaAdjust:
+			d.adjustWeight(change)
+		case deltaRemove:
+			delete(d.Weights, change.path)
+		case deltaImport:
+			d.importEntry(change.entry)
+		}
+	}
+	log.Debug().Int("deltas", len(d.deltas)).Int("entries", len(d.Weights)).Msg("merged database")
+	return nil
+}
+
 // Prune removes entries from the database that no longer exist.
 func (d *GobDatabase) Prune(maxEntries int, excludePatterns []string) {
 	// delete non-existent entries
-	for path := range d.Weights {
-		st, err := os.Stat(path)
-		if err != nil {
-			log.Debug().Err(err).Str("path", path).Msg("failed to stat file")
-			delete(d.Weights, path)
-			d.dirty = true
-			continue
-		}
-		if !st.IsDir() {
-			log.Debug().Str("path", path).Msg("removing non-directory entry")
-			delete(d.Weights, path)
-			d.dirty = true
+	for path, weight := range d.Weights {
+		if err := CheckKind(path, weight.Kind); err != nil {
+			log.Debug().Err(err).Str("path", path).Str("kind", weight.Kind.String()).Msg("removing invalid entry")
+			d.Remove(path)
 			continue
 		}
 		for _, pattern := range excludePatterns {
 			if strings.Contains(path, pattern) {
 				log.Debug().Str("path", path).Str("pattern", pattern).Msg("removing path matching exclude pattern")
-				delete(d.Weights, path)
-				d.dirty = true
+				d.Remove(path)
 				break
 			}
 		}
@@ -106,47 +147,113 @@ func (d *GobDatabase) Prune(maxEntries int, excludePatterns []string) {
 		entries := toEntryList(d.Weights)
 		sort.Sort(ascendingWeight(entries))
 		for i, entry := range entries {
-			delete(d.Weights, entry.Path)
+			d.Remove(entry.Path)
 			if i == deleteCount-1 {
 				break
 			}
 		}
-		d.dirty = true
 	}
 }
 
+// LoadError returns the error encountered decoding the database, if any. A
+// database that failed to load is read-only, so that a corrupt database file
+// is never overwritten.
+func (d *GobDatabase) LoadError() error {
+	return d.loadErr
+}
+
 // Save atomically saves the database.
 func (d *GobDatabase) Save(w io.Writer) error {
-	enc := gob.NewEncoder(w)
-	if err := enc.Encode(d.Weights); err != nil {
+	if d.loadErr != nil {
+		log.Error().Err(d.loadErr).Msg("refusing to save database that failed to load")
+		return ErrReadOnly
+	}
+	if d.opts.ranker().Age(d.Weights) {
+		log.Debug().Int("entries", len(d.Weights)).Msg("aged weights")
+	}
+	if d.opts.Aging.Apply(d.Weights, time.Now().UTC()) {
+		log.Debug().Int("entries", len(d.Weights)).Msg("decayed weights")
+	}
+	if err := encodeWeights(w, d.Weights); err != nil {
 		log.Error().Err(err).Msg("failed to encode gob database")
 		return err
 	}
 	d.dirty = false
+	d.replaced = false
+	d.deltas = nil
 	return nil
 }
 
 // Search searches for the best database entry.
 func (d *GobDatabase) Search(count int, needles ...string) []Entry {
-	s := NewSearcher(d.Weights, d.opts)
+	s := NewSearcher(d.searchWeights(), d.opts)
+	d.runPipeline(s, needles)
 
-	// Assume all components form the suffix of the directory name.
-	needle := filepath.Join(needles...)
+	// find the best match
+	return d.best(s, count)
+}
 
-	// first check exact suffix matches
-	exact := needle
-	if !strings.HasPrefix(exact, "/") {
-		exact = "/" + needle
+// Explain explains how every candidate for a search was scored.
+func (d *GobDatabase) Explain(needles ...string) []Explanation {
+	s := NewSearcher(d.searchWeights(), d.opts)
+	s.EnableExplain()
+	d.runPipeline(s, needles)
+	return s.Explanations()
+}
+
+// searchWeights returns the weights to search, restricted as requested by the
+// options.
+func (d *GobDatabase) searchWeights() weightMap {
+	weights := d.Weights
+	if d.opts.Repo != "" {
+		weights = withinRepo(weights, d.opts.Repo)
+	}
+	if d.opts.RepoRoots {
+		weights = repoWeights(weights)
+	}
+	kinds := d.opts.Kinds
+	if len(kinds) == 0 {
+		kinds = []Kind{KindDir}
 	}
-	s.Search(exact, strings.HasSuffix, 10.)
+	return withKinds(weights, kinds)
+}
+
+// withKinds returns the weights for paths of the given kinds.
+func withKinds(weights weightMap, kinds []Kind) weightMap {
+	filtered := make(weightMap)
+	for path, weight := range weights {
+		for _, kind := range kinds {
+			if weight.Kind == kind {
+				filtered[path] = weight
+				break
+			}
+		}
+	}
+	return filtered
+}
 
-	// next check regular suffix matches
-	s.Search(needle, strings.HasSuffix, 2.5)
+// runPipeline runs the search pipeline for needles.
+func (d *GobDatabase) runPipeline(s *Searcher, needles []string) {
+	pipeline := d.opts.Pipeline
+	if pipeline == nil {
+		pipeline = DefaultPipeline(d.opts)
+	}
+	for _, pass := range pipeline.Passes {
+		s.Run(pass, needles...)
+	}
+	s.boostProximity()
+}
 
-	// next try any contains matches
-	s.Search(needle, strings.Contains, 1.)
+// SearchCompare searches for the best database entries matching a single
+// comparison function.
+func (d *GobDatabase) SearchCompare(count int, needle string, cmp StringCompare) []Entry {
+	s := NewSearcher(d.searchWeights(), d.opts)
+	s.Search(needle, cmp, 1.)
+	return d.best(s, count)
+}
 
-	// find the best match
+// best returns the best search results, removing paths that no longer exist.
+func (d *GobDatabase) best(s *Searcher, count int) []Entry {
 	results, errorPaths := s.Best(count)
 
 	// if any errors were encountered, remove those paths
@@ -163,13 +270,57 @@ func (d *GobDatabase) GetWeights() []Entry {
 	return toEntryList(d.Weights)
 }
 
+// Import merges entries into the database. Weights of existing entries are
+// combined the same way AdjustWeight combines them, keeping the newer
+// timestamp.
+func (d *GobDatabase) Import(entries []Entry) ImportSummary {
+	var summary ImportSummary
+	for _, entry := range entries {
+		if !validImport(entry) {
+			log.Debug().Str("path", entry.Path).Float64("weight", entry.Weight).Msg("skipping invalid import entry")
+			summary.Skipped++
+			continue
+		}
+		d.dirty = true
+		d.deltas = append(d.deltas, delta{kind: deltaImport, path: entry.Path, at: time.Now().UTC(), entry: entry})
+		if d.importEntry(entry) {
+			summary.Added++
+		} else {
+			summary.Updated++
+		}
+	}
+	return summary
+}
+
+// importEntry implements Import for a single entry without recording a
+// delta. Returns true if the entry is new.
+func (d *GobDatabase) importEntry(entry Entry) bool {
+	current, ok := d.Weights[entry.Path]
+	if !ok {
+		d.Weights[entry.Path] = entry.weight()
+		return true
+	}
+	current.Value = math.Sqrt(current.Value*current.Value + entry.Weight*entry.Weight)
+	if entry.UpdatedAt.After(current.UpdatedAt) {
+		current.UpdatedAt = entry.UpdatedAt
+	}
+	if current.Repo == "" {
+		current.Repo = entry.Repo
+	}
+	current.mergeVisits(entry.weight())
+	d.Weights[entry.Path] = current
+	return false
+}
+
 // Replace replaces the underlying weight map.
 func (d *GobDatabase) Replace(entries []Entry) {
 	d.Weights = make(weightMap)
 	for _, entry := range entries {
-		d.Weights[entry.Path] = Weight{Value: entry.Weight, UpdatedAt: entry.UpdatedAt}
+		d.Weights[entry.Path] = entry.weight()
 	}
 	d.dirty = true
+	d.replaced = true
+	d.deltas = nil
 }
 
 // NewGobDatabase loads a database file.
@@ -178,9 +329,21 @@ func NewGobDatabase(r io.Reader, opts Options) *GobDatabase {
 		opts:    opts,
 		Weights: make(weightMap),
 	}
-	dec := gob.NewDecoder(r)
-	if err := dec.Decode(&db.Weights); err != nil && err != io.EOF {
+	weights, version, err := decodeWeights(r)
+	if err != nil {
 		log.Error().Err(err).Msg("failed to decode weights for gob database")
+		db.loadErr = err
+		return db
+	}
+	db.Weights = weights
+
+	// age weights as of now, so that searches see decayed weights; the
+	// result is saved along with the next change
+	db.opts.Aging.Apply(db.Weights, time.Now().UTC())
+	if version < formatVersion {
+		// rewrite the database in the current format on the next save
+		log.Info().Uint32("from", version).Uint32("to", formatVersion).Msg("upgrading database format")
+		db.dirty = true
 	}
 	return db
 }
@@ -58,7 +58,7 @@ func (s *MySuite) TestDatabaseEndToEnd(c *C) {
 	}
 
 	// remove the non-existent directory
-	handle.Prune(100)
+	handle.Prune(100, nil)
 	c.Assert(handle.Weights, HasLen, 1)
 
 	c.Assert(db.Dump(handle, db.DumpOpts{}), Not(IsNil))
@@ -80,6 +80,6 @@ func (s *MySuite) TestDatabaseEndToEnd(c *C) {
 	c.Assert(g.Close(), IsNil)
 	handle.AdjustWeight(nonDir, 1)
 
-	handle.Prune(3)
+	handle.Prune(3, nil)
 	c.Assert(handle.Weights, HasLen, 3)
 }
@@ -19,8 +19,10 @@ package db
 import (
 	"bufio"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -33,9 +35,42 @@ const (
 	autojumpDbFile = "autojump.txt"
 )
 
+// ImportSummary summarizes the result of importing entries.
+type ImportSummary struct {
+	Added   int // entries that were new
+	Updated int // entries that were merged into existing entries
+	Skipped int // invalid entries that were skipped
+}
+
+// validImport checks that an imported entry is usable.
+func validImport(entry Entry) bool {
+	return filepath.IsAbs(entry.Path) && entry.Weight > 0 && !math.IsInf(entry.Weight, 0) && !math.IsNaN(entry.Weight)
+}
+
+// AutojumpDatabasePaths returns the locations autojump may keep its database
+// in, in the order they should be checked.
+func AutojumpDatabasePaths() []string {
+	home := dirOrTmp(os.UserHomeDir())
+	paths := []string{filepath.Join(dataDir(), autojumpVendor, autojumpDbFile)}
+	if runtime.GOOS == "darwin" {
+		paths = append([]string{filepath.Join(home, "Library", autojumpVendor, autojumpDbFile)}, paths...)
+	}
+	return append(paths,
+		filepath.Join(home, ".local", "share", autojumpVendor, autojumpDbFile),
+		filepath.Join(dirOrTmp(os.UserCacheDir()), autojumpVendor, autojumpDbFile),
+	)
+}
+
+// FindAutojumpDatabase returns the first autojump database that exists, or the
+// empty string if none is found.
 func FindAutojumpDatabase() string {
-	// XXX: Or is it config dir? I forget
-	return filepath.Join(dirOrTmp(os.UserCacheDir()), autojumpVendor, autojumpDbFile)
+	for _, path := range AutojumpDatabasePaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		log.Debug().Str("path", path).Msg("autojump database not found")
+	}
+	return ""
 }
 
 // LoadAutojumpDatabase loads the autojump database file
@@ -17,7 +17,13 @@
 package db_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/eklitzke/jump/db"
 	. "gopkg.in/check.v1"
@@ -29,3 +35,72 @@ func (s *MySuite) TestImport(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(weights, HasLen, 2)
 }
+
+func (s *MySuite) TestImportMerge(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight("/foo", 3)
+	updatedAt := handle.Weights["/foo"].UpdatedAt
+
+	summary := handle.Import([]db.Entry{
+		{Path: "/foo", Weight: 4, UpdatedAt: updatedAt.Add(-time.Hour)},
+		{Path: "/bar", Weight: 1, UpdatedAt: updatedAt},
+		{Path: "relative", Weight: 1},
+		{Path: "/baz", Weight: -1},
+	})
+	c.Assert(summary, Equals, db.ImportSummary{Added: 1, Updated: 1, Skipped: 2})
+	c.Assert(handle.Weights, HasLen, 2)
+	c.Assert(handle.Weights["/foo"].Value, Equals, 5.)
+	c.Assert(handle.Weights["/foo"].UpdatedAt, Equals, updatedAt)
+	c.Assert(handle.Weights["/bar"].Value, Equals, 1.)
+}
+
+func (s *MySuite) TestImportZ(c *C) {
+	r := strings.NewReader("/foo|10|1500000000\n/a|b|2.5|1500000001\nbad\n/baz|x|1\n")
+	entries, err := db.LoadZDatabase(r)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0], DeepEquals, db.Entry{Path: "/foo", Weight: 10, UpdatedAt: time.Unix(1500000000, 0).UTC()})
+	c.Assert(entries[1].Path, Equals, "/a|b")
+
+	entries, err = db.LoadFasdDatabase(strings.NewReader("/foo|1.5|1500000000\n"))
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+}
+
+func (s *MySuite) TestImportZoxide(c *C) {
+	buf := new(bytes.Buffer)
+	write := func(v interface{}) { c.Assert(binary.Write(buf, binary.LittleEndian, v), IsNil) }
+	write(uint32(3))
+	write(uint64(2))
+	for i, path := range []string{"/foo", "/bar/baz"} {
+		write(uint64(len(path)))
+		buf.WriteString(path)
+		write(float64(i + 1))
+		write(uint64(1500000000 + i))
+	}
+
+	entries, err := db.LoadZoxideDatabase(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[1], DeepEquals, db.Entry{Path: "/bar/baz", Weight: 2, UpdatedAt: time.Unix(1500000001, 0).UTC()})
+
+	// truncated databases are an error
+	_, err = db.LoadZoxideDatabase(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *MySuite) TestFindAutojumpDatabase(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	oldDataHome := os.Getenv("XDG_DATA_HOME")
+	defer os.Setenv("XDG_DATA_HOME", oldDataHome)
+	c.Assert(os.Setenv("XDG_DATA_HOME", baseDir), IsNil)
+
+	path := filepath.Join(baseDir, "autojump", "autojump.txt")
+	c.Assert(db.AutojumpDatabasePaths(), Not(HasLen), 0)
+	c.Assert(db.FindAutojumpDatabase(), Not(Equals), path)
+
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte("1.0\t/foo\n"), 0644), IsNil)
+	c.Assert(db.FindAutojumpDatabase(), Equals, path)
+}
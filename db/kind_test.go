@@ -0,0 +1,72 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestParseKind(c *C) {
+	for _, kind := range []db.Kind{db.KindDir, db.KindFile} {
+		parsed, err := db.ParseKind(kind.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, kind)
+	}
+	_, err := db.ParseKind("socket")
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *MySuite) TestSearchKinds(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	dir := filepath.Join(baseDir, "notes")
+	file := filepath.Join(baseDir, "notes.txt")
+	c.Assert(os.Mkdir(dir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(file, []byte("hello"), 0644), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(dir, 1)
+	handle.AdjustWeight(file, 100)
+
+	// only directories are searched by default
+	entries := handle.Search(2, "notes")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, dir)
+	c.Assert(entries[0].Kind, Equals, db.KindDir)
+
+	handle = db.NewGobDatabase(strings.NewReader(""), db.Options{Kinds: []db.Kind{db.KindFile}})
+	handle.AdjustWeight(dir, 1)
+	handle.AdjustWeight(file, 100)
+	entries = handle.Search(2, "notes")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, file)
+	c.Assert(entries[0].Kind, Equals, db.KindFile)
+
+	// files aren't pruned, unless they're replaced by a directory
+	handle.Prune(0, nil)
+	c.Assert(handle.GetWeights(), HasLen, 2)
+	c.Assert(os.Remove(file), IsNil)
+	c.Assert(os.Mkdir(file, 0755), IsNil)
+	handle.Prune(0, nil)
+	c.Assert(handle.GetWeights(), HasLen, 1)
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// lockPollInterval is how often we retry a contended lock.
+const lockPollInterval = 10 * time.Millisecond
+
+// ErrLockTimeout is returned by AcquireLock when the lock could not be
+// acquired before the timeout expired.
+var ErrLockTimeout = errors.New("timed out waiting for database lock")
+
+// FileLock is an advisory lock held on a sidecar lock file.
+type FileLock struct {
+	f *os.File
+}
+
+// LockPath returns the path of the lock file guarding a database file.
+func LockPath(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// AcquireLock takes an exclusive flock on the given path, creating the file if
+// necessary. If the lock is held by another process we keep retrying until the
+// timeout expires, in which case ErrLockTimeout is returned.
+func AcquireLock(path string, timeout time.Duration) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &FileLock{f: f}, nil
+		}
+		if err != syscall.EWOULDBLOCK && err != syscall.EINTR {
+			_ = f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, ErrLockTimeout
+		}
+		log.Debug().Str("path", path).Msg("waiting for database lock")
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Release releases the lock.
+func (l *FileLock) Release() error {
+	if err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN); err != nil {
+		_ = l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
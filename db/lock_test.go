@@ -0,0 +1,45 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFileLock(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+
+	path := db.LockPath(filepath.Join(baseDir, "db.gob"))
+	lock, err := db.AcquireLock(path, time.Second)
+	c.Assert(err, IsNil)
+
+	// a second lock on the same file should time out
+	_, err = db.AcquireLock(path, 50*time.Millisecond)
+	c.Assert(err, Equals, db.ErrLockTimeout)
+
+	// after releasing, the lock can be acquired again
+	c.Assert(lock.Release(), IsNil)
+	lock, err = db.AcquireLock(path, time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(lock.Release(), IsNil)
+}
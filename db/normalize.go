@@ -0,0 +1,110 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalize converts s to Unicode normalization form C, so that paths and
+// queries compare equal regardless of how they were composed (e.g. NFD paths
+// from macOS). If fold is true, diacritics are removed as well.
+func normalize(s string, fold bool) string {
+	if !fold {
+		return norm.NFC.String(s)
+	}
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		log.Debug().Err(err).Str("string", s).Msg("failed to fold diacritics")
+		return norm.NFC.String(s)
+	}
+	return folded
+}
+
+// hasUpper checks if s contains any uppercase characters.
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// needleKey returns the form of needle to compare against paths, and whether
+// the comparison should ignore case. With smart case, the comparison is case
+// insensitive unless the needle contains uppercase characters.
+func (s *Searcher) needleKey(needle string) (string, bool) {
+	needle = normalize(needle, s.opts.FoldDiacritics)
+	return needle, s.opts.SmartCase && !hasUpper(needle)
+}
+
+// query builds the query for needles, and whether matching should ignore
+// case. Absolute paths among the needles, like the current directory jc
+// prepends to the query, don't affect smart case unless the query is made up
+// only of absolute paths.
+func (s *Searcher) query(needles []string) (Query, bool) {
+	q := Query{}
+	ignoreTerms, ignorePaths := true, true
+	for _, needle := range needles {
+		key, ignore := s.needleKey(needle)
+		q.Needles = append(q.Needles, key)
+		if filepath.IsAbs(needle) {
+			ignorePaths = ignorePaths && ignore
+		} else {
+			ignoreTerms = ignoreTerms && ignore
+		}
+	}
+	ignoreCase := ignoreTerms
+	if len(needles) > 0 && allAbs(needles) {
+		ignoreCase = ignorePaths
+	}
+	if ignoreCase {
+		for i, needle := range q.Needles {
+			q.Needles[i] = strings.ToLower(needle)
+		}
+	}
+	q.Needle = filepath.Join(q.Needles...)
+	return q, ignoreCase
+}
+
+// allAbs checks if all of the needles are absolute paths.
+func allAbs(needles []string) bool {
+	for _, needle := range needles {
+		if !filepath.IsAbs(needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathKey returns the form of path to compare against a needle.
+func (s *Searcher) pathKey(path string, ignoreCase bool) string {
+	key := s.normalized[path]
+	if ignoreCase {
+		return strings.ToLower(key)
+	}
+	return key
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestSmartCaseAndNormalization(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	docs := filepath.Join(baseDir, "Documents")
+	cafe := filepath.Join(baseDir, "cafe\u0301") // NFD encoded
+	c.Assert(os.MkdirAll(docs, 0755), IsNil)
+	c.Assert(os.MkdirAll(cafe, 0755), IsNil)
+
+	search := func(opts db.Options, query string) []db.Entry {
+		handle := db.NewGobDatabase(strings.NewReader(""), opts)
+		handle.AdjustWeight(docs, 1)
+		handle.AdjustWeight(cafe, 1)
+		return handle.Search(1, query)
+	}
+
+	// smart case
+	c.Assert(search(db.Options{}, "doc"), HasLen, 0)
+	c.Assert(search(db.Options{SmartCase: true}, "doc"), HasLen, 1)
+	c.Assert(search(db.Options{SmartCase: true}, "Doc"), HasLen, 1)
+	c.Assert(search(db.Options{SmartCase: true}, "DOC"), HasLen, 0)
+
+	// NFC queries match NFD paths
+	c.Assert(search(db.Options{}, "caf\u00e9"), HasLen, 1)
+
+	// diacritic folding
+	c.Assert(search(db.Options{}, "cafe"), HasLen, 0)
+	entries := search(db.Options{FoldDiacritics: true}, "cafe")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, cafe)
+}
+
+func (s *MySuite) TestSmartCaseIgnoresCwdNeedle(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	home := filepath.Join(baseDir, "Users", "me")
+	docs := filepath.Join(home, "Documents")
+	c.Assert(os.MkdirAll(docs, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{SmartCase: true})
+	handle.AdjustWeight(docs, 1)
+
+	// like jc, which prepends the current directory to the query
+	entries := handle.Search(1, home, "doc")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, docs)
+
+	// uppercase in the user's own terms still makes the search case
+	// sensitive
+	c.Assert(handle.Search(1, home, "DOC"), HasLen, 0)
+	c.Assert(handle.Search(1, home, "Doc"), HasLen, 1)
+}
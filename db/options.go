@@ -18,6 +18,43 @@ package db
 
 // Options represent database options.
 type Options struct {
-	Debug        bool // debug setting
-	TimeMatching bool // enable time matching
+	Debug          bool // debug setting
+	TimeMatching   bool // enable time matching
+	Fuzzy          bool // enable fuzzy matching
+	SmartCase      bool // case insensitive unless the query has uppercase
+	FoldDiacritics bool // ignore diacritics when matching
+
+	// Cwd is the current directory, which is demoted below every other
+	// search result so that jumping doesn't just stay in place.
+	Cwd string
+
+	// Proximity boosts search results near Cwd.
+	Proximity bool
+
+	// RepoRoots restricts searches to repository roots.
+	RepoRoots bool
+
+	// Repo restricts searches to paths within this repository root.
+	Repo string
+
+	// Kinds are the kinds of entries to search, directories if empty.
+	Kinds []Kind
+
+	// Pipeline overrides the default search pipeline.
+	Pipeline *Pipeline
+
+	// Ranker is the ranking algorithm, ClassicRanker if nil.
+	Ranker Ranker
+
+	// Aging decays weights over time, in addition to any aging done by
+	// the ranker.
+	Aging Aging
+}
+
+// ranker returns the ranking algorithm.
+func (o Options) ranker() Ranker {
+	if o.Ranker == nil {
+		return ClassicRanker
+	}
+	return o.Ranker
 }
@@ -0,0 +1,57 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RegexCompare compiles a regular expression into a StringCompare that
+// matches paths against it, ignoring the needle.
+func RegexCompare(pattern string) (StringCompare, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(path, _ string) bool { return re.MatchString(path) }, nil
+}
+
+// GlobCompare compiles a shell glob into a StringCompare that matches whole
+// paths against it, ignoring the needle. A leading ~ is expanded to the home
+// directory. As with filepath.Match, wildcards don't match slashes.
+func GlobCompare(pattern string) (StringCompare, error) {
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		pattern = home + pattern[1:]
+	}
+
+	// check the pattern now, so bad patterns are an error rather than
+	// silently matching nothing
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(path, _ string) bool {
+		matched, _ := filepath.Match(pattern, path)
+		return matched
+	}, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestPatternCompare(c *C) {
+	_, err := db.RegexCompare("(")
+	c.Assert(err, Not(IsNil))
+	_, err = db.GlobCompare("[")
+	c.Assert(err, Not(IsNil))
+
+	cmp, err := db.RegexCompare("^/srv/.*/logs$")
+	c.Assert(err, IsNil)
+	c.Assert(cmp("/srv/app/logs", ""), Equals, true)
+	c.Assert(cmp("/srv/app/logs/old", ""), Equals, false)
+
+	cmp, err = db.GlobCompare("/src/*/cmd")
+	c.Assert(err, IsNil)
+	c.Assert(cmp("/src/jump/cmd", ""), Equals, true)
+	c.Assert(cmp("/src/a/b/cmd", ""), Equals, false)
+
+	home, err := os.UserHomeDir()
+	c.Assert(err, IsNil)
+	cmp, err = db.GlobCompare("~/src/*")
+	c.Assert(err, IsNil)
+	c.Assert(cmp(filepath.Join(home, "src", "jump"), ""), Equals, true)
+}
+
+func (s *MySuite) TestSearchCompare(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	logs := filepath.Join(baseDir, "app", "logs")
+	c.Assert(os.MkdirAll(logs, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(logs, 1)
+	handle.AdjustWeight(baseDir, 10)
+	cmp, err := db.RegexCompare("/logs$")
+	c.Assert(err, IsNil)
+	entries := handle.SearchCompare(5, "/logs$", cmp)
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, logs)
+}
+
+func (s *MySuite) TestSearchCompareIsCaseSensitive(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	logs := filepath.Join(baseDir, "Logs")
+	c.Assert(os.MkdirAll(logs, 0755), IsNil)
+
+	// patterns are matched against paths as they are, even with smart case
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{SmartCase: true})
+	handle.AdjustWeight(logs, 1)
+	cmp, err := db.RegexCompare("/logs$")
+	c.Assert(err, IsNil)
+	c.Assert(handle.SearchCompare(1, "/logs$", cmp), HasLen, 0)
+	cmp, err = db.RegexCompare(`/\SOGS$`)
+	c.Assert(err, IsNil)
+	c.Assert(handle.SearchCompare(1, `/\SOGS$`, cmp), HasLen, 0)
+	cmp, err = db.RegexCompare("/Logs$")
+	c.Assert(err, IsNil)
+	c.Assert(handle.SearchCompare(1, "/Logs$", cmp), HasLen, 1)
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Query is a search query, as seen by matchers. Needles are normalized the
+// same way as the paths they're matched against.
+type Query struct {
+	Needles []string // the query arguments
+	Needle  string   // the arguments joined into a single path
+}
+
+// Matcher scores how well a path matches a query. The score should be in the
+// range [0, 1], where 0 means the path doesn't match.
+type Matcher interface {
+	Match(path string, q Query) float64
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(path string, q Query) float64
+
+// Match calls f(path, q).
+func (f MatcherFunc) Match(path string, q Query) float64 {
+	return f(path, q)
+}
+
+// CompareMatcher adapts a StringCompare to the Matcher interface; paths score
+// 1 if they compare true against the joined needle.
+func CompareMatcher(cmp StringCompare) Matcher {
+	return MatcherFunc(func(path string, q Query) float64 {
+		if cmp(path, q.Needle) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Built in matchers.
+var (
+	// ExactSuffixMatcher matches paths ending with the query as complete
+	// path components.
+	ExactSuffixMatcher Matcher = MatcherFunc(func(path string, q Query) float64 {
+		needle := q.Needle
+		if !strings.HasPrefix(needle, "/") {
+			needle = "/" + needle
+		}
+		if strings.HasSuffix(path, needle) {
+			return 1
+		}
+		return 0
+	})
+
+	// SuffixMatcher matches paths ending with the query.
+	SuffixMatcher = CompareMatcher(strings.HasSuffix)
+
+	// ContainsMatcher matches paths containing the query.
+	ContainsMatcher = CompareMatcher(strings.Contains)
+
+	// ComponentMatcher matches the query arguments against path components,
+	// see ComponentScore.
+	ComponentMatcher Matcher = MatcherFunc(func(path string, q Query) float64 {
+		return ComponentScore(path, q.Needles)
+	})
+
+	// FuzzyMatcher matches the query as a subsequence of the path, see
+	// FuzzyScore.
+	FuzzyMatcher Matcher = MatcherFunc(func(path string, q Query) float64 {
+		return FuzzyScore(path, q.Needle)
+	})
+)
+
+// Scorer computes the initial score of a search candidate from its weight.
+type Scorer interface {
+	Score(path string, w Weight) float64
+}
+
+// ScorerFunc adapts a function to the Scorer interface.
+type ScorerFunc func(path string, w Weight) float64
+
+// Score calls f(path, w).
+func (f ScorerFunc) Score(path string, w Weight) float64 {
+	return f(path, w)
+}
+
+// Built in scorers.
+var (
+	// WeightScorer scores candidates by their weight.
+	WeightScorer Scorer = ScorerFunc(func(_ string, w Weight) float64 {
+		return w.Value
+	})
+
+	// RecencyScorer scores candidates by their weight, divided by the log
+	// of the number of seconds since they were last updated.
+	RecencyScorer Scorer = ScorerFunc(func(_ string, w Weight) float64 {
+		elapsed := time.Since(w.UpdatedAt).Seconds()
+		if elapsed > 0 {
+			return w.Value / math.Log1p(elapsed)
+		}
+		return w.Value
+	})
+)
+
+// Pass is a single search pass. Candidates matched by the pass have their
+// score multiplied by Alpha times the match score.
+type Pass struct {
+	Name       string  // the name of the pass
+	Matcher    Matcher // the matcher used by the pass
+	Alpha      float64 // how much a match in this pass is worth
+	Fallback   bool    // only run if earlier passes found nothing
+	MinNeedles int     // only run for queries with at least this many arguments
+}
+
+// Pipeline is an ordered list of search passes, and the scorer for new
+// candidates.
+type Pipeline struct {
+	Passes []Pass
+	Scorer Scorer
+}
+
+// DefaultPipeline returns the default search pipeline for the options.
+func DefaultPipeline(opts Options) *Pipeline {
+	p := &Pipeline{
+		Passes: []Pass{
+			{Name: "exact-suffix", Matcher: ExactSuffixMatcher, Alpha: 10},
+			{Name: "suffix", Matcher: SuffixMatcher, Alpha: 2.5},
+			{Name: "contains", Matcher: ContainsMatcher, Alpha: 1},
+
+			// with multiple needles, match them against path
+			// components like autojump does, so "foo bar" can match
+			// /foo/x/bar
+			{Name: "components", Matcher: ComponentMatcher, Alpha: 2, MinNeedles: 2},
+		},
+		Scorer: WeightScorer,
+	}
+	if opts.Fuzzy {
+		// fuzzy matches are only tried if nothing matched otherwise,
+		// so they never outrank regular matches
+		p.Add(Pass{Name: "fuzzy", Matcher: FuzzyMatcher, Alpha: .5, Fallback: true})
+	}
+	if opts.TimeMatching {
+		p.Scorer = opts.ranker()
+	}
+	return p
+}
+
+// Add appends a pass to the pipeline.
+func (p *Pipeline) Add(pass Pass) {
+	p.Passes = append(p.Passes, pass)
+}
+
+// Remove removes the named pass from the pipeline, returning false if there's
+// no such pass.
+func (p *Pipeline) Remove(name string) bool {
+	for i, pass := range p.Passes {
+		if pass.Name == name {
+			p.Passes = append(p.Passes[:i], p.Passes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetAlpha changes the alpha of the named pass, returning false if there's no
+// such pass.
+func (p *Pipeline) SetAlpha(name string, alpha float64) bool {
+	for i := range p.Passes {
+		if p.Passes[i].Name == name {
+			p.Passes[i].Alpha = alpha
+			return true
+		}
+	}
+	return false
+}
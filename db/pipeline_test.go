@@ -0,0 +1,66 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestPipeline(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	foo := filepath.Join(baseDir, "foo")
+	bar := filepath.Join(baseDir, "bar")
+	c.Assert(os.MkdirAll(foo, 0755), IsNil)
+	c.Assert(os.MkdirAll(bar, 0755), IsNil)
+
+	pipeline := db.DefaultPipeline(db.Options{})
+	c.Assert(pipeline.SetAlpha("contains", 3), Equals, true)
+	c.Assert(pipeline.SetAlpha("nope", 3), Equals, false)
+	c.Assert(pipeline.Remove("exact-suffix"), Equals, true)
+	c.Assert(pipeline.Remove("exact-suffix"), Equals, false)
+
+	// a custom pass that matches everything ending in "bar"
+	pipeline.Add(db.Pass{
+		Name: "bar",
+		Matcher: db.MatcherFunc(func(path string, q db.Query) float64 {
+			if strings.HasSuffix(path, "bar") {
+				return 1
+			}
+			return 0
+		}),
+		Alpha: 1,
+	})
+
+	// and a custom scorer that ignores weights
+	pipeline.Scorer = db.ScorerFunc(func(path string, w db.Weight) float64 { return 1 })
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{Pipeline: pipeline})
+	handle.AdjustWeight(foo, 100)
+	handle.AdjustWeight(bar, 1)
+	entries := handle.Search(2, "foo")
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0].Path, Equals, foo)
+	c.Assert(entries[0].Weight, Equals, 3*2.5)
+	c.Assert(entries[1].Path, Equals, bar)
+	c.Assert(entries[1].Weight, Equals, 1.)
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// proximityAlpha is the extra boost given to a candidate one directory away
+// from the current directory. Candidates further away get proportionally less.
+const proximityAlpha = 2.
+
+// Proximity returns the boost for path when searching from cwd. Descendants
+// and siblings of cwd, and paths in the same repository as cwd (rooted at
+// repoRoot, which may be empty), are boosted by 1 + proximityAlpha/d where d is
+// the number of directories between them. Other paths aren't boosted.
+func Proximity(path, cwd, repoRoot string) float64 {
+	pathParts := splitPath(path)
+	cwdParts := splitPath(cwd)
+	common := 0
+	for common < len(pathParts) && common < len(cwdParts) && pathParts[common] == cwdParts[common] {
+		common++
+	}
+	distance := len(pathParts) - common + len(cwdParts) - common
+	if distance == 0 {
+		return 1
+	}
+
+	descendant := common == len(cwdParts)
+	sibling := common == len(cwdParts)-1 && len(pathParts) == len(cwdParts)
+	sameRepo := repoRoot != "" && isWithin(path, repoRoot)
+	if !descendant && !sibling && !sameRepo {
+		return 1
+	}
+	return 1 + proximityAlpha/float64(distance)
+}
+
+// splitPath splits a cleaned path into its components.
+func splitPath(path string) []string {
+	path = filepath.Clean(path)
+	if path == string(filepath.Separator) {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(path, string(filepath.Separator)), string(filepath.Separator))
+}
+
+// isWithin checks if path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// boostProximity boosts every candidate by its proximity to the current
+// directory.
+func (s *Searcher) boostProximity() {
+	if !s.opts.Proximity || s.opts.Cwd == "" {
+		return
+	}
+	repoRoot := FindRepoRoot(s.opts.Cwd)
+	for path, weight := range s.output {
+		boost := Proximity(path, s.opts.Cwd, repoRoot)
+		if boost == 1 {
+			continue
+		}
+		weight.Value *= boost
+		s.output[path] = weight
+		if e, ok := s.explain[path]; ok {
+			e.Proximity = boost
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestProximity(c *C) {
+	c.Assert(db.Proximity("/a/b", "/a/b", ""), Equals, 1.)
+	c.Assert(db.Proximity("/x/y", "/a/b", ""), Equals, 1.)
+
+	// descendants and siblings are boosted, less so further away
+	c.Assert(db.Proximity("/a/b/c", "/a/b", ""), Equals, 3.)
+	c.Assert(db.Proximity("/a/b/c/d", "/a/b", ""), Equals, 2.)
+	c.Assert(db.Proximity("/a/c", "/a/b", ""), Equals, 2.)
+
+	// cousins are only boosted in the same repository
+	c.Assert(db.Proximity("/a/c/d", "/a/b/e", ""), Equals, 1.)
+	c.Assert(db.Proximity("/a/c/d", "/a/b/e", "/a"), Equals, 1.5)
+	c.Assert(db.Proximity("/ab/c", "/a/b", "/a"), Equals, 1.)
+}
+
+func (s *MySuite) TestSearchProximity(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	repo := filepath.Join(baseDir, "repo")
+	local := filepath.Join(repo, "test")
+	other := filepath.Join(baseDir, "other", "test")
+	c.Assert(os.MkdirAll(filepath.Join(repo, ".git"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(repo, "src"), 0755), IsNil)
+	c.Assert(os.MkdirAll(local, 0755), IsNil)
+	c.Assert(os.MkdirAll(other, 0755), IsNil)
+
+	opts := db.Options{Cwd: filepath.Join(repo, "src")}
+	handle := db.NewGobDatabase(strings.NewReader(""), opts)
+	handle.AdjustWeight(local, 10)
+	handle.AdjustWeight(other, 15)
+	c.Assert(handle.Search(1, "test")[0].Path, Equals, other)
+
+	opts.Proximity = true
+	handle = db.NewGobDatabase(strings.NewReader(""), opts)
+	handle.AdjustWeight(local, 10)
+	handle.AdjustWeight(other, 15)
+	c.Assert(handle.Search(1, "test")[0].Path, Equals, local)
+}
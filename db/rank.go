@@ -0,0 +1,153 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ranker is a ranking algorithm. It controls how weights accumulate as paths
+// are visited, how they age over time, and (as a Scorer) how recency affects
+// search results.
+type Ranker interface {
+	Scorer
+
+	// Adjust returns the new weight of a path after a positive adjustment.
+	Adjust(current, weight float64) float64
+
+	// Age ages the weights in place before the database is saved,
+	// returning true if anything changed.
+	Age(weights map[string]Weight) bool
+}
+
+// Built in rankers.
+var (
+	// ClassicRanker is jump's original algorithm: weights accumulate as
+	// sqrt(a^2 + b^2), never age, and are divided by the log of the number
+	// of seconds since the path was last visited.
+	ClassicRanker Ranker = classicRanker{}
+
+	// ZRanker mimics z: each visit adds one to the rank, recency is scored
+	// in hour/day/week buckets, and when the total rank exceeds 9000 all
+	// ranks are scaled by 0.99.
+	ZRanker Ranker = zRanker{}
+
+	// ZoxideRanker mimics zoxide: each visit adds one to the rank, recency
+	// is scored in hour/day/week buckets, and when the total rank exceeds
+	// 10000 all ranks are scaled down to 90% of that.
+	ZoxideRanker Ranker = zoxideRanker{}
+)
+
+// Rankers maps ranking algorithm names to rankers.
+var Rankers = map[string]Ranker{
+	"classic": ClassicRanker,
+	"z":       ZRanker,
+	"zoxide":  ZoxideRanker,
+}
+
+// RankerNames returns the sorted list of ranker names.
+func RankerNames() []string {
+	var names []string
+	for name := range Rankers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RankerByName looks up a ranker, returning false if there's no such ranker.
+func RankerByName(name string) (Ranker, bool) {
+	r, ok := Rankers[strings.ToLower(name)]
+	return r, ok
+}
+
+type classicRanker struct{}
+
+func (classicRanker) Score(path string, w Weight) float64 { return RecencyScorer.Score(path, w) }
+func (classicRanker) Adjust(current, weight float64) float64 {
+	return math.Sqrt(current*current + weight*weight)
+}
+func (classicRanker) Age(map[string]Weight) bool { return false }
+
+// frecency scales a weight by how recently it was updated, using the buckets
+// z and zoxide use.
+func frecency(w Weight) float64 {
+	elapsed := time.Since(w.UpdatedAt)
+	switch {
+	case elapsed < time.Hour:
+		return w.Value * 4
+	case elapsed < 24*time.Hour:
+		return w.Value * 2
+	case elapsed < 7*24*time.Hour:
+		return w.Value / 2
+	}
+	return w.Value / 4
+}
+
+// totalWeight sums the weights.
+func totalWeight(weights map[string]Weight) float64 {
+	total := 0.
+	for _, w := range weights {
+		total += w.Value
+	}
+	return total
+}
+
+// scaleWeights multiplies all weights by factor, dropping those below one.
+func scaleWeights(weights map[string]Weight, factor float64) {
+	for path, w := range weights {
+		w.Value *= factor
+		if w.Value < 1 {
+			delete(weights, path)
+			continue
+		}
+		weights[path] = w
+	}
+}
+
+const (
+	zMaxTotal      = 9000  // z ages ranks once their total exceeds this
+	zoxideMaxTotal = 10000 // zoxide's default _ZO_MAXAGE
+)
+
+type zRanker struct{}
+
+func (zRanker) Score(_ string, w Weight) float64       { return frecency(w) }
+func (zRanker) Adjust(current, weight float64) float64 { return current + 1 }
+func (zRanker) Age(weights map[string]Weight) bool {
+	if totalWeight(weights) <= zMaxTotal {
+		return false
+	}
+	scaleWeights(weights, .99)
+	return true
+}
+
+type zoxideRanker struct{}
+
+func (zoxideRanker) Score(_ string, w Weight) float64       { return frecency(w) }
+func (zoxideRanker) Adjust(current, weight float64) float64 { return current + 1 }
+func (zoxideRanker) Age(weights map[string]Weight) bool {
+	total := totalWeight(weights)
+	if total <= zoxideMaxTotal {
+		return false
+	}
+	scaleWeights(weights, .9*zoxideMaxTotal/total)
+	return true
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestRankerByName(c *C) {
+	for _, name := range db.RankerNames() {
+		_, ok := db.RankerByName(name)
+		c.Assert(ok, Equals, true)
+	}
+	r, ok := db.RankerByName("Zoxide")
+	c.Assert(ok, Equals, true)
+	c.Assert(r, Equals, db.ZoxideRanker)
+	_, ok = db.RankerByName("nope")
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MySuite) TestRankerAdjust(c *C) {
+	c.Assert(db.ClassicRanker.Adjust(3, 4), Equals, 5.)
+	c.Assert(db.ZRanker.Adjust(3, 15), Equals, 4.)
+	c.Assert(db.ZoxideRanker.Adjust(3, 15), Equals, 4.)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{Ranker: db.ZRanker})
+	handle.AdjustWeight("/foo", 15)
+	handle.AdjustWeight("/foo", 15)
+	c.Assert(handle.Weights["/foo"].Value, Equals, 2.)
+}
+
+func (s *MySuite) TestRankerScore(c *C) {
+	now := time.Now()
+	for _, tc := range []struct {
+		age   time.Duration
+		score float64
+	}{
+		{time.Minute, 40},
+		{2 * time.Hour, 20},
+		{2 * 24 * time.Hour, 5},
+		{30 * 24 * time.Hour, 2.5},
+	} {
+		w := db.Weight{Value: 10, UpdatedAt: now.Add(-tc.age)}
+		c.Assert(db.ZRanker.Score("/foo", w), Equals, tc.score)
+		c.Assert(db.ZoxideRanker.Score("/foo", w), Equals, tc.score)
+	}
+}
+
+func (s *MySuite) TestRankerAge(c *C) {
+	weights := func(n int, value float64) map[string]db.Weight {
+		w := make(map[string]db.Weight)
+		for i := 0; i < n; i++ {
+			w[fmt.Sprintf("/path/%d", i)] = db.Weight{Value: value}
+		}
+		return w
+	}
+
+	w := weights(10, 1)
+	c.Assert(db.ClassicRanker.Age(w), Equals, false)
+	c.Assert(db.ZRanker.Age(w), Equals, false)
+	c.Assert(db.ZoxideRanker.Age(w), Equals, false)
+
+	// z scales by 0.99 and drops entries below 1
+	w = weights(100, 100)
+	w["/small"] = db.Weight{Value: 1}
+	c.Assert(db.ZRanker.Age(w), Equals, true)
+	c.Assert(w, HasLen, 100)
+	c.Assert(w["/path/0"].Value, Equals, 99.)
+
+	// zoxide scales the total down to 9000
+	w = weights(100, 200)
+	c.Assert(db.ZoxideRanker.Age(w), Equals, true)
+	c.Assert(w["/path/0"].Value, Equals, 90.)
+}
@@ -0,0 +1,272 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxSalvagePathLen bounds the length of paths recognized while scanning a
+// corrupt payload.
+const maxSalvagePathLen = 4096
+
+// ErrReadOnly is returned when trying to save a database that failed to load.
+var ErrReadOnly = errors.New("database failed to load and is read-only")
+
+// Salvage recovers whatever entries can be decoded from a corrupt database.
+// The payload is first decoded normally, ignoring any checksum mismatch; if
+// that fails the payload is scanned for individually decodable entries.
+func Salvage(r io.Reader) ([]Entry, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version, payload := uint32(0), data
+	if bytes.HasPrefix(data, []byte(formatMagic)) {
+		var hdr formatHeader
+		br := bytes.NewReader(data)
+		if err := binary.Read(br, binary.BigEndian, &hdr); err == nil {
+			version = hdr.Version
+			payload = data[len(data)-br.Len():]
+			if uint64(len(payload)) > hdr.Length {
+				payload = payload[:hdr.Length]
+			}
+		}
+	}
+
+	if migrated, err := migratePayload(version, payload); err == nil {
+		weights := make(weightMap)
+		if err := gob.NewDecoder(bytes.NewReader(migrated)).Decode(&weights); err == nil {
+			log.Debug().Int("entries", len(weights)).Msg("decoded corrupt database ignoring checksum")
+			return validEntries(toEntryList(weights)), nil
+		}
+	}
+
+	entries := scanEntries(payload)
+	log.Debug().Int("entries", len(entries)).Msg("salvaged entries by scanning")
+	return entries, nil
+}
+
+// validEntries filters out entries that can't be right.
+func validEntries(entries []Entry) []Entry {
+	var valid []Entry
+	for _, entry := range entries {
+		if entry.Path == "" || entry.Weight <= 0 || math.IsInf(entry.Weight, 0) || math.IsNaN(entry.Weight) {
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	return valid
+}
+
+// scanEntries scans a gob encoded weightMap for map entries that can be
+// decoded on their own. Each map entry is a string key followed by a Weight
+// struct, so we look for length prefixed absolute paths and try to decode a
+// Weight immediately after each one.
+func scanEntries(payload []byte) []Entry {
+	var entries []Entry
+	seen := make(map[string]bool)
+	for i := 0; i < len(payload); i++ {
+		length, n, ok := gobUint(payload[i:])
+		if !ok || length == 0 || length > maxSalvagePathLen {
+			continue
+		}
+		start := i + n
+		end := start + int(length)
+		if end > len(payload) || payload[start] != '/' {
+			continue
+		}
+		path := payload[start:end]
+		if !utf8.Valid(path) || bytes.IndexByte(path, 0) != -1 {
+			continue
+		}
+		weight, size, ok := gobWeight(payload[end:])
+		if !ok || seen[string(path)] {
+			continue
+		}
+		seen[string(path)] = true
+		entries = append(entries, newEntry(string(path), weight))
+		i = end + size - 1
+	}
+	return validEntries(entries)
+}
+
+// gobUint decodes a gob unsigned integer, returning the value and the number
+// of bytes consumed.
+func gobUint(b []byte) (uint64, int, bool) {
+	if len(b) == 0 {
+		return 0, 0, false
+	}
+	if b[0] < 0x80 {
+		return uint64(b[0]), 1, true
+	}
+	n := -int(int8(b[0]))
+	if n > 8 || len(b) < n+1 {
+		return 0, 0, false
+	}
+	var x uint64
+	for _, c := range b[1 : n+1] {
+		x = x<<8 | uint64(c)
+	}
+	return x, n + 1, true
+}
+
+// gobInt decodes a gob signed integer, returning the value and the number of
+// bytes consumed.
+func gobInt(b []byte) (int64, int, bool) {
+	bits, n, ok := gobUint(b)
+	if !ok {
+		return 0, 0, false
+	}
+	// gob encodes the sign in the low bit
+	x := int64(bits >> 1)
+	if bits&1 != 0 {
+		x = ^x
+	}
+	return x, n, true
+}
+
+// gobBytes decodes a length prefixed gob byte string, returning the bytes and
+// the number of bytes consumed.
+func gobBytes(b []byte) ([]byte, int, bool) {
+	length, n, ok := gobUint(b)
+	if !ok || length > uint64(len(b)-n) {
+		return nil, 0, false
+	}
+	return b[n : n+int(length)], n + int(length), true
+}
+
+// gobTime decodes a gob encoded time.Time, returning the time and the number
+// of bytes consumed.
+func gobTime(b []byte) (time.Time, int, bool) {
+	var t time.Time
+	data, n, ok := gobBytes(b)
+	if !ok || t.UnmarshalBinary(data) != nil {
+		return t, 0, false
+	}
+	return t, n, true
+}
+
+// gobWeight decodes a gob encoded Weight struct, returning the weight and the
+// number of bytes consumed.
+func gobWeight(b []byte) (Weight, int, bool) {
+	var w Weight
+	pos, field := 0, -1
+	for {
+		delta, n, ok := gobUint(b[pos:])
+		if !ok {
+			return w, 0, false
+		}
+		pos += n
+		if delta == 0 {
+			return w, pos, true
+		}
+		field += int(delta)
+		switch field {
+		case 0: // Value
+			bits, n, ok := gobUint(b[pos:])
+			if !ok {
+				return w, 0, false
+			}
+			pos += n
+			// gob encodes floats with their bytes reversed
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], bits)
+			w.Value = math.Float64frombits(binary.BigEndian.Uint64(buf[:]))
+		case 1: // UpdatedAt
+			if w.UpdatedAt, n, ok = gobTime(b[pos:]); !ok {
+				return w, 0, false
+			}
+			pos += n
+		case 2: // Repo
+			repo, n, ok := gobBytes(b[pos:])
+			if !ok || !utf8.Valid(repo) {
+				return w, 0, false
+			}
+			w.Repo = string(repo)
+			pos += n
+		case 3: // Kind
+			kind, n, ok := gobInt(b[pos:])
+			if !ok {
+				return w, 0, false
+			}
+			w.Kind = Kind(kind)
+			pos += n
+		case 4: // Visits
+			visits, n, ok := gobInt(b[pos:])
+			if !ok || visits < 0 {
+				return w, 0, false
+			}
+			w.Visits = int(visits)
+			pos += n
+		case 5: // FirstSeen
+			if w.FirstSeen, n, ok = gobTime(b[pos:]); !ok {
+				return w, 0, false
+			}
+			pos += n
+		case 6: // Recent
+			length, n, ok := gobUint(b[pos:])
+			if !ok || length != maxRecentVisits {
+				return w, 0, false
+			}
+			pos += n
+			for i := range w.Recent {
+				if w.Recent[i], n, ok = gobTime(b[pos:]); !ok {
+					return w, 0, false
+				}
+				pos += n
+			}
+		case 7: // Daily
+			length, n, ok := gobUint(b[pos:])
+			if !ok || length != MaxVisitDays {
+				return w, 0, false
+			}
+			pos += n
+			for i := range w.Daily {
+				count, n, ok := gobUint(b[pos:])
+				if !ok || count > math.MaxUint32 {
+					return w, 0, false
+				}
+				w.Daily[i] = uint32(count)
+				pos += n
+			}
+		case 8: // LastDay
+			if w.LastDay, n, ok = gobInt(b[pos:]); !ok {
+				return w, 0, false
+			}
+			pos += n
+		case 9: // AgedAt
+			if w.AgedAt, n, ok = gobTime(b[pos:]); !ok {
+				return w, 0, false
+			}
+			pos += n
+		default:
+			return w, 0, false
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) savedDatabase(c *C, count int) []byte {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	for i := 0; i < count; i++ {
+		handle.AdjustWeight(fmt.Sprintf("/path/%d", i), float64(i+1))
+	}
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+	return buf.Bytes()
+}
+
+func (s *MySuite) TestCorruptDatabaseIsReadOnly(c *C) {
+	data := s.savedDatabase(c, 10)
+	handle := db.NewGobDatabase(bytes.NewReader(data[:len(data)/2]), db.Options{})
+	c.Assert(handle.LoadError(), Not(IsNil))
+
+	handle.AdjustWeight("/foo", 1)
+	c.Assert(handle.Save(new(bytes.Buffer)), Equals, db.ErrReadOnly)
+}
+
+func (s *MySuite) TestSalvageChecksumMismatch(c *C) {
+	data := s.savedDatabase(c, 10)
+	data[16] ^= 0x1 // the first byte of the header checksum
+	entries, err := db.Salvage(bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 10)
+}
+
+func (s *MySuite) TestSalvageTruncated(c *C) {
+	data := s.savedDatabase(c, 100)
+	entries, err := db.Salvage(bytes.NewReader(data[:len(data)/2]))
+	c.Assert(err, IsNil)
+	c.Assert(len(entries) > 0, Equals, true)
+	c.Assert(len(entries) < 100, Equals, true)
+	for _, entry := range entries {
+		c.Assert(strings.HasPrefix(entry.Path, "/path/"), Equals, true)
+		c.Assert(entry.Weight > 0, Equals, true)
+		c.Assert(entry.UpdatedAt.IsZero(), Equals, false)
+	}
+}
+
+// fillValue sets v, and everything in it, to non-zero values derived from
+// seed.
+func fillValue(c *C, v reflect.Value, seed int) {
+	switch v.Kind() {
+	case reflect.Float64:
+		v.SetFloat(float64(seed) + .5)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(seed))
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(seed))
+	case reflect.String:
+		v.SetString(fmt.Sprintf("/repo/%d", seed))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillValue(c, v.Index(i), seed+i)
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Unix(int64(1500000000+seed), 0).UTC()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fillValue(c, v.Field(i), seed+i)
+		}
+	default:
+		c.Fatalf("don't know how to fill in a %s", v.Type())
+	}
+}
+
+func (s *MySuite) TestSalvageAllFields(c *C) {
+	// every field is filled in, so Salvage has to know about all of them
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	for i := 0; i < 100; i++ {
+		var w db.Weight
+		fillValue(c, reflect.ValueOf(&w).Elem(), 1000+i)
+		handle.Weights[fmt.Sprintf("/path/%d", i)] = w
+	}
+	expected := make(map[string]db.Entry)
+	for _, entry := range handle.GetWeights() {
+		expected[entry.Path] = entry
+	}
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+
+	// truncating the payload forces entries to be scanned individually
+	data := buf.Bytes()
+	salvaged, err := db.Salvage(bytes.NewReader(data[:len(data)/2]))
+	c.Assert(err, IsNil)
+	c.Assert(len(salvaged) > 10, Equals, true)
+	for _, entry := range salvaged {
+		c.Assert(entry, DeepEquals, expected[entry.Path])
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// repoMarkers are the entries that mark the root of a repository.
+var repoMarkers = []string{".git", ".hg"}
+
+// FindRepoRoot returns the root of the git or mercurial repository containing
+// dir, or "" if dir isn't in a repository. Only the filesystem is consulted.
+func FindRepoRoot(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		for _, marker := range repoMarkers {
+			if _, err := os.Lstat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// repoWeights returns a weight for each repository root recorded in weights.
+// The weight of a root combines the weights of every path in the repository
+// the same way AdjustWeight does, with the most recent timestamp.
+func repoWeights(weights weightMap) weightMap {
+	repos := make(weightMap)
+	for _, weight := range weights {
+		if weight.Repo == "" {
+			continue
+		}
+		repo := repos[weight.Repo]
+		repo.Value = math.Sqrt(repo.Value*repo.Value + weight.Value*weight.Value)
+		if weight.UpdatedAt.After(repo.UpdatedAt) {
+			repo.UpdatedAt = weight.UpdatedAt
+		}
+		repo.Repo = weight.Repo
+		repo.mergeVisits(weight)
+		repos[weight.Repo] = repo
+	}
+	return repos
+}
+
+// withinRepo returns the weights for paths within the repository root.
+func withinRepo(weights weightMap, root string) weightMap {
+	within := make(weightMap)
+	for path, weight := range weights {
+		if isWithin(path, root) {
+			within[path] = weight
+		}
+	}
+	return within
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFindRepoRoot(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	repo := filepath.Join(baseDir, "repo")
+	nested := filepath.Join(repo, "a", "b")
+	c.Assert(os.MkdirAll(nested, 0755), IsNil)
+	c.Assert(os.Mkdir(filepath.Join(repo, ".git"), 0755), IsNil)
+
+	c.Assert(db.FindRepoRoot(nested), Equals, repo)
+	c.Assert(db.FindRepoRoot(repo), Equals, repo)
+	c.Assert(db.FindRepoRoot(baseDir), Equals, "")
+
+	// mercurial repositories are detected too
+	hg := filepath.Join(baseDir, "hg")
+	c.Assert(os.MkdirAll(filepath.Join(hg, ".hg"), 0755), IsNil)
+	c.Assert(db.FindRepoRoot(hg), Equals, hg)
+}
+
+func (s *MySuite) TestUpdateRecordsRepo(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	repo := filepath.Join(baseDir, "repo")
+	nested := filepath.Join(repo, "pkg")
+	c.Assert(os.MkdirAll(filepath.Join(repo, ".git"), 0755), IsNil)
+	c.Assert(os.MkdirAll(nested, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(nested, 1)
+	handle.AdjustWeight(baseDir, 1)
+	weights := make(map[string]db.Entry)
+	for _, entry := range handle.GetWeights() {
+		weights[entry.Path] = entry
+	}
+	c.Assert(weights[nested].Repo, Equals, repo)
+	c.Assert(weights[baseDir].Repo, Equals, "")
+
+	// the metadata survives a save and load
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+	handle = db.NewGobDatabase(buf, db.Options{})
+	c.Assert(handle.Search(1, "pkg")[0].Repo, Equals, repo)
+}
+
+func (s *MySuite) TestSearchRepos(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	alpha := filepath.Join(baseDir, "alpha")
+	beta := filepath.Join(baseDir, "beta")
+	for _, dir := range []string{alpha, beta} {
+		c.Assert(os.MkdirAll(filepath.Join(dir, ".git"), 0755), IsNil)
+		c.Assert(os.MkdirAll(filepath.Join(dir, "db"), 0755), IsNil)
+	}
+	notRepo := filepath.Join(baseDir, "alphabet")
+	c.Assert(os.MkdirAll(notRepo, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{RepoRoots: true})
+	handle.AdjustWeight(filepath.Join(alpha, "db"), 1)
+	handle.AdjustWeight(filepath.Join(beta, "db"), 1)
+	handle.AdjustWeight(notRepo, 100)
+
+	// repository roots are found even if they weren't visited themselves
+	entries := handle.Search(2, "alpha")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, alpha)
+	c.Assert(handle.Search(1, "db"), HasLen, 0)
+
+	handle = db.NewGobDatabase(strings.NewReader(""), db.Options{Repo: beta})
+	handle.AdjustWeight(filepath.Join(alpha, "db"), 10)
+	handle.AdjustWeight(filepath.Join(beta, "db"), 1)
+	entries = handle.Search(2, "db")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, filepath.Join(beta, "db"))
+}
+
+func (s *MySuite) TestSalvageRepo(c *C) {
+	var entries []db.Entry
+	for i := 0; i < 100; i++ {
+		entries = append(entries, db.Entry{
+			Path:      fmt.Sprintf("/repo/%d", i),
+			Weight:    float64(i + 1),
+			UpdatedAt: time.Now().UTC(),
+			Repo:      "/repo",
+			Kind:      db.Kind(i % 2),
+		})
+	}
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Replace(entries)
+	buf := new(bytes.Buffer)
+	c.Assert(handle.Save(buf), IsNil)
+	data := buf.Bytes()
+
+	salvaged, err := db.Salvage(bytes.NewReader(data[:len(data)/2]))
+	c.Assert(err, IsNil)
+	c.Assert(len(salvaged) > 0, Equals, true)
+	for _, entry := range salvaged {
+		var i int
+		_, err := fmt.Sscanf(entry.Path, "/repo/%d", &i)
+		c.Assert(err, IsNil)
+		c.Assert(entry.Repo, Equals, "/repo")
+		c.Assert(entry.Kind, Equals, db.Kind(i%2))
+	}
+}
@@ -17,9 +17,9 @@
 package db
 
 import (
-	"math"
+	"path/filepath"
 	"sort"
-	"time"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -29,37 +29,116 @@ type StringCompare func(string, string) bool
 
 // Searcher implements the matching algorithm.
 type Searcher struct {
-	input  weightMap // read-only input weights
-	output weightMap // output weights
-	opts   Options   // options
+	input      weightMap               // read-only input weights
+	normalized map[string]string       // normalized forms of the input paths
+	output     weightMap               // output weights
+	scorer     Scorer                  // scores new candidates
+	opts       Options                 // options
+	explain    map[string]*Explanation // explanations, if enabled
 }
 
 // Search searches for the needle in the input list using the given comparator,
-// and modulates the weight by alpha.
+// and modulates the weight by alpha. Unlike Run, the comparator sees the paths
+// and the needle exactly as they are, without any case or Unicode handling.
 func (s *Searcher) Search(needle string, cmp StringCompare, alpha float64) {
-	log.Debug().Str("needle", needle).Float64("alpha", alpha).Msg("doing search")
+	pass := Pass{Name: "compare", Matcher: CompareMatcher(cmp), Alpha: alpha}
+	log.Debug().Str("pass", pass.Name).Str("needle", needle).Float64("alpha", alpha).Msg("doing search")
+	q := Query{Needle: needle, Needles: []string{needle}}
+	s.run(pass, q, func(path string) string { return path })
+}
+
+// Run runs a search pass for the query made up of needles. Each matching path
+// has its weight modulated by the pass alpha scaled by the match score.
+func (s *Searcher) Run(pass Pass, needles ...string) {
+	if len(needles) < pass.MinNeedles {
+		return
+	}
+	if pass.Fallback && len(s.output) > 0 {
+		return
+	}
+	log.Debug().Str("pass", pass.Name).Strs("needles", needles).Float64("alpha", pass.Alpha).Msg("doing search")
+	q, ignoreCase := s.query(needles)
+	s.run(pass, q, func(path string) string { return s.pathKey(path, ignoreCase) })
+}
+
+// run matches every input path against q, using key to get the form of each
+// path to match.
+func (s *Searcher) run(pass Pass, q Query, key func(string) string) {
 	for path, inputWeight := range s.input {
-		if cmp(path, needle) {
-			if w, ok := s.output[path]; ok {
-				w.Value *= alpha
-				s.output[path] = w
-			} else {
-				beta := alpha
-				if s.opts.TimeMatching {
-					elapsed := time.Since(inputWeight.UpdatedAt).Seconds()
-					if elapsed > 0 {
-						beta /= math.Log1p(elapsed)
-					}
-				}
-				log.Debug().Float64("alpha", alpha).Float64("beta", beta).Str("path", path).Float64("initial_weight", inputWeight.Value).Msg("new search candidate")
-				inputWeight.Value *= beta
-				s.output[path] = inputWeight
-			}
+		w, ok := s.output[path]
+		score := pass.Matcher.Match(key(path), q)
+		if score <= 0 {
+			continue
+		}
+		s.explainMatch(path, inputWeight, pass, score)
+		if ok {
+			w.Value *= pass.Alpha * score
+			s.output[path] = w
+		} else {
+			s.addCandidate(path, inputWeight, pass.Alpha*score)
 		}
 	}
 }
 
-// Best returns the best matching entry that is actually a directory.
+// splitComponents splits a path into its non-empty components.
+func splitComponents(path string) []string {
+	var components []string
+	for _, component := range strings.Split(path, "/") {
+		if component != "" {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// ComponentScore matches needles against the components of path the way
+// autojump does: each needle must be a substring of a path component, in
+// order, and the last needle must match the final component. Needles
+// containing slashes are split into one needle per component. Each matched
+// component scores the fraction of it covered by its needle, and the result
+// is the average score, in the range (0, 1]; 0 means no match.
+func ComponentScore(path string, needles []string) float64 {
+	var parts []string
+	for _, needle := range needles {
+		parts = append(parts, splitComponents(needle)...)
+	}
+	components := splitComponents(path)
+	if len(parts) == 0 || len(parts) > len(components) {
+		return 0
+	}
+
+	// the last needle must match the final component
+	last := components[len(components)-1]
+	if !strings.Contains(last, parts[len(parts)-1]) {
+		return 0
+	}
+	score := float64(len(parts[len(parts)-1])) / float64(len(last))
+
+	// the rest must match earlier components, in order
+	i := 0
+	for _, part := range parts[:len(parts)-1] {
+		for i < len(components)-1 && !strings.Contains(components[i], part) {
+			i++
+		}
+		if i == len(components)-1 {
+			return 0
+		}
+		score += float64(len(part)) / float64(len(components[i]))
+		i++
+	}
+	return score / float64(len(parts))
+}
+
+// addCandidate adds a new search candidate, modulating its score by alpha.
+func (s *Searcher) addCandidate(path string, inputWeight Weight, alpha float64) {
+	score := s.scorer.Score(path, inputWeight)
+	log.Debug().Float64("alpha", alpha).Float64("score", score).Str("path", path).Float64("initial_weight", inputWeight.Value).Msg("new search candidate")
+	inputWeight.Value = score * alpha
+	s.output[path] = inputWeight
+}
+
+// Best returns the best matching entries that still exist, along with the
+// entries that don't.
 func (s *Searcher) Best(count int) ([]Entry, []string) {
 	var errorPaths []string
 	entries := toEntryList(s.output)
@@ -70,25 +149,48 @@ func (s *Searcher) Best(count int) ([]Entry, []string) {
 		}
 	}
 
+	var cwd string
+	if s.opts.Cwd != "" {
+		cwd = filepath.Clean(s.opts.Cwd)
+	}
+
 	var results []Entry
+	var current []Entry
 	for _, entry := range entries {
-		if err := CheckIsDir(entry.Path); err != nil {
+		if err := CheckKind(entry.Path, entry.Kind); err != nil {
 			errorPaths = append(errorPaths, entry.Path)
 			continue
 		}
+		if entry.Path == cwd {
+			current = append(current, entry)
+			continue
+		}
 		results = append(results, entry)
 		if len(results) >= count {
-			break
+			return results, errorPaths
 		}
 	}
+
+	// the current directory is only returned if nothing else matched
+	results = append(results, current...)
 	return results, errorPaths
 }
 
 // NewSearcher creates a new searcher instance.
 func NewSearcher(input weightMap, opts Options) *Searcher {
+	normalized := make(map[string]string, len(input))
+	for path := range input {
+		normalized[path] = normalize(path, opts.FoldDiacritics)
+	}
+	scorer := DefaultPipeline(opts).Scorer
+	if opts.Pipeline != nil && opts.Pipeline.Scorer != nil {
+		scorer = opts.Pipeline.Scorer
+	}
 	return &Searcher{
-		input:  input,
-		output: make(weightMap),
-		opts:   opts,
+		input:      input,
+		normalized: normalized,
+		output:     make(weightMap),
+		scorer:     scorer,
+		opts:       opts,
 	}
 }
@@ -0,0 +1,89 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestComponentScore(c *C) {
+	c.Assert(db.ComponentScore("/foo/x/bar", []string{"foo", "bar"}), Equals, 1.)
+	c.Assert(db.ComponentScore("/foo/x/barbaz", []string{"foo", "bar"}), Equals, .75)
+	c.Assert(db.ComponentScore("/home/me/foo/bar", []string{"/home/me", "bar"}), Equals, 1.)
+
+	// the last needle must match the final component
+	c.Assert(db.ComponentScore("/foo/bar/x", []string{"foo", "bar"}), Equals, 0.)
+
+	// needles must match in order, each against a different component
+	c.Assert(db.ComponentScore("/bar/foo", []string{"bar", "foo", "foo"}), Equals, 0.)
+	c.Assert(db.ComponentScore("/foo/bar", []string{"bar", "foo", "bar"}), Equals, 0.)
+	c.Assert(db.ComponentScore("/foo", []string{"foo", "foo"}), Equals, 0.)
+}
+
+func (s *MySuite) TestSearchComponents(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	nested := filepath.Join(baseDir, "foo", "x", "bar")
+	exact := filepath.Join(baseDir, "foo", "bar")
+	c.Assert(os.MkdirAll(nested, 0755), IsNil)
+	c.Assert(os.MkdirAll(exact, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight(nested, 1)
+	entries := handle.Search(2, "foo", "bar")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, nested)
+
+	// exact matches are still preferred
+	handle.AdjustWeight(exact, 1)
+	entries = handle.Search(2, "foo", "bar")
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0].Path, Equals, exact)
+}
+
+func (s *MySuite) TestSearchCwd(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+	here := filepath.Join(baseDir, "a", "src")
+	there := filepath.Join(baseDir, "b", "src")
+	c.Assert(os.MkdirAll(here, 0755), IsNil)
+	c.Assert(os.MkdirAll(there, 0755), IsNil)
+
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{Cwd: here + "/"})
+	handle.AdjustWeight(here, 100)
+	handle.AdjustWeight(there, 1)
+
+	// the current directory is demoted below the next best match
+	entries := handle.Search(1, "src")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, there)
+
+	entries = handle.Search(2, "src")
+	c.Assert(entries, HasLen, 2)
+	c.Assert(entries[0].Path, Equals, there)
+	c.Assert(entries[1].Path, Equals, here)
+
+	// but it's still returned when nothing else matches
+	entries = handle.Search(1, "a/src")
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Path, Equals, here)
+}
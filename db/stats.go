@@ -0,0 +1,138 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// StatsOptions control how database statistics are computed.
+type StatsOptions struct {
+	Top        int           // the number of most visited and recent entries
+	StaleAfter time.Duration // entries not visited for this long are stale
+	Days       int           // the number of days to count visits for
+	Now        time.Time     // the current time
+}
+
+// Percentile is a percentile of the weight distribution.
+type Percentile struct {
+	Percent int     `json:"percent"`
+	Weight  float64 `json:"weight"`
+}
+
+// Stats summarizes the database.
+type Stats struct {
+	Entries     int          `json:"entries"`     // the number of entries
+	Dirs        int          `json:"dirs"`        // the number of directory entries
+	Files       int          `json:"files"`       // the number of file entries
+	Weights     []Percentile `json:"weights"`     // percentiles of the weights
+	MostVisited []Entry      `json:"mostVisited"` // the most visited entries
+	MostRecent  []Entry      `json:"mostRecent"`  // the most recently visited entries
+	Stale       int          `json:"stale"`       // entries not visited for StaleAfter
+	Missing     int          `json:"missing"`     // entries whose paths no longer exist
+	Visits      []DayVisits  `json:"visits"`      // recent visits per day, oldest first
+}
+
+// statsPercentiles are the percentiles of the weight distribution reported.
+var statsPercentiles = []int{10, 25, 50, 75, 90, 99, 100}
+
+// ComputeStats computes statistics about the entries. Visits per day are
+// only counted for the last MaxVisitDays days.
+func ComputeStats(entries []Entry, opts StatsOptions) Stats {
+	stats := Stats{Entries: len(entries)}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindFile:
+			stats.Files++
+		default:
+			stats.Dirs++
+		}
+		if opts.Now.Sub(entry.LastVisit()) > opts.StaleAfter {
+			stats.Stale++
+		}
+		if !pathExists(entry.Path, entry.Kind) {
+			stats.Missing++
+		}
+	}
+	if len(entries) == 0 {
+		return stats
+	}
+
+	sorted := append([]Entry(nil), entries...)
+	sort.Sort(ascendingWeight(sorted))
+	for _, p := range statsPercentiles {
+		// nearest rank method
+		rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		stats.Weights = append(stats.Weights, Percentile{Percent: p, Weight: sorted[rank-1].Weight})
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Visits > sorted[j].Visits })
+	stats.MostVisited = topEntries(sorted, opts.Top)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LastVisit().After(sorted[j].LastVisit()) })
+	stats.MostRecent = topEntries(sorted, opts.Top)
+
+	if opts.Days > 0 {
+		year, month, day := opts.Now.Date()
+		today := time.Date(year, month, day, 0, 0, 0, 0, opts.Now.Location())
+		first := today.AddDate(0, 0, 1-opts.Days)
+		for i := 0; i < opts.Days; i++ {
+			stats.Visits = append(stats.Visits, DayVisits{Day: first.AddDate(0, 0, i)})
+		}
+		for _, entry := range entries {
+			for _, v := range entry.Daily {
+				y, m, d := v.Day.Date()
+				day := time.Date(y, m, d, 0, 0, 0, 0, opts.Now.Location())
+				if day.Before(first) || day.After(today) {
+					continue
+				}
+				// count calendar days, which aren't always 24 hours
+				i := int(math.Round(day.Sub(first).Hours() / 24))
+				stats.Visits[i].Visits += v.Visits
+			}
+		}
+	}
+	return stats
+}
+
+// topEntries returns up to n entries from the start of entries.
+func topEntries(entries []Entry, n int) []Entry {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(entries) {
+		n = len(entries)
+	}
+	return append([]Entry(nil), entries[:n]...)
+}
+
+// pathExists checks if path exists and is of the given kind, without logging.
+func pathExists(path string, kind Kind) bool {
+	st, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if kind == KindFile {
+		return st.Mode().IsRegular()
+	}
+	return st.IsDir()
+}
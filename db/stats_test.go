@@ -0,0 +1,126 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestComputeStats(c *C) {
+	baseDir := s.createTempDir(c)
+	defer os.RemoveAll(baseDir)
+
+	now := time.Date(2019, time.October, 10, 12, 0, 0, 0, time.UTC)
+	var entries []db.Entry
+	for i := 1; i <= 10; i++ {
+		entries = append(entries, db.Entry{
+			Path:      fmt.Sprintf("%s/missing/%d", baseDir, i),
+			Weight:    float64(i),
+			UpdatedAt: now.Add(-time.Duration(i) * 24 * time.Hour),
+			Visits:    i,
+			Daily:     []db.DayVisits{{Day: now.Add(-time.Duration(i) * 24 * time.Hour), Visits: 1}},
+		})
+	}
+	entries = append(entries, db.Entry{
+		Path:      baseDir,
+		Weight:    100,
+		UpdatedAt: now,
+		Visits:    1,
+		Daily:     []db.DayVisits{{Day: now, Visits: 2}},
+	})
+
+	stats := db.ComputeStats(entries, db.StatsOptions{
+		Top:        3,
+		StaleAfter: 7 * 24 * time.Hour,
+		Days:       7,
+		Now:        now,
+	})
+	c.Assert(stats.Entries, Equals, 11)
+	c.Assert(stats.Dirs, Equals, 11)
+	c.Assert(stats.Missing, Equals, 10)
+	c.Assert(stats.Stale, Equals, 3)
+
+	c.Assert(stats.Weights[2], Equals, db.Percentile{Percent: 50, Weight: 6})
+	c.Assert(stats.Weights[len(stats.Weights)-1], Equals, db.Percentile{Percent: 100, Weight: 100})
+
+	c.Assert(stats.MostVisited, HasLen, 3)
+	c.Assert(stats.MostVisited[0].Visits, Equals, 10)
+	c.Assert(stats.MostRecent[0].Path, Equals, baseDir)
+
+	// visits are counted per day, for the last week including today
+	c.Assert(stats.Visits, HasLen, 7)
+	c.Assert(stats.Visits[0].Day.Equal(time.Date(2019, time.October, 4, 0, 0, 0, 0, time.UTC)), Equals, true)
+	c.Assert(stats.Visits[6].Visits, Equals, 2)
+	c.Assert(stats.Visits[5].Visits, Equals, 1)
+	c.Assert(stats.Visits[0].Visits, Equals, 1)
+
+	none := db.ComputeStats(entries, db.StatsOptions{Top: -1, Now: now})
+	c.Assert(none.MostVisited, HasLen, 0)
+	c.Assert(none.MostRecent, HasLen, 0)
+
+	empty := db.ComputeStats(nil, db.StatsOptions{Top: 3})
+	c.Assert(empty.Entries, Equals, 0)
+	c.Assert(empty.Weights, HasLen, 0)
+}
+
+func (s *MySuite) TestStatsStaleByLastVisit(c *C) {
+	now := time.Date(2019, time.October, 10, 12, 0, 0, 0, time.UTC)
+	entries := []db.Entry{
+		// lowering a weight updates the entry without visiting it
+		{Path: "/lowered", Weight: 1, UpdatedAt: now, Recent: []time.Time{now.Add(-30 * 24 * time.Hour)}},
+		{Path: "/visited", Weight: 1, UpdatedAt: now.Add(-time.Hour), Recent: []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)}},
+	}
+	stats := db.ComputeStats(entries, db.StatsOptions{
+		Top:        2,
+		StaleAfter: 7 * 24 * time.Hour,
+		Now:        now,
+	})
+	c.Assert(stats.Stale, Equals, 1)
+	c.Assert(stats.MostRecent[0].Path, Equals, "/visited")
+	c.Assert(stats.MostRecent[1].Path, Equals, "/lowered")
+}
+
+func (s *MySuite) TestStatsCountsEveryVisit(c *C) {
+	// paths visited more often than the recent visits remembered for them
+	// still have every visit counted
+	now := time.Date(2019, time.October, 10, 12, 0, 0, 0, time.Local)
+	var recent []time.Time
+	for i := 10; i > 0; i-- {
+		recent = append(recent, now.Add(-time.Duration(i)*time.Minute))
+	}
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Import([]db.Entry{{
+		Path:      "/foo",
+		Weight:    1,
+		UpdatedAt: now,
+		Visits:    25,
+		Recent:    recent,
+		Daily:     []db.DayVisits{{Day: now, Visits: 25}},
+	}})
+	entries := handle.GetWeights()
+	c.Assert(entries[0].Recent, HasLen, 10)
+
+	stats := db.ComputeStats(entries, db.StatsOptions{Days: 7, Now: now})
+	c.Assert(stats.Visits, HasLen, 7)
+	c.Assert(stats.Visits[6].Visits, Equals, 25)
+}
@@ -0,0 +1,29 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import "strings"
+
+// tsvEscaper escapes the characters that would break up a TSV record.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// EscapeTSV escapes a field of a tab separated record, so that tabs and
+// newlines in it can't be mistaken for field or record separators. They're
+// written as \t, \n and \r, and backslashes are doubled.
+func EscapeTSV(field string) string {
+	return tsvEscaper.Replace(field)
+}
@@ -0,0 +1,35 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"strings"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestEscapeTSV(c *C) {
+	c.Assert(db.EscapeTSV("/home/me/src"), Equals, "/home/me/src")
+
+	escaped := db.EscapeTSV("/tmp/a\tb\nc\rd\\e")
+	c.Assert(escaped, Equals, `/tmp/a\tb\nc\rd\\e`)
+	c.Assert(strings.ContainsAny(escaped, "\t\n\r"), Equals, false)
+
+	// a literal backslash-t is distinguishable from an escaped tab
+	c.Assert(db.EscapeTSV(`/tmp/a\tb`), Not(Equals), db.EscapeTSV("/tmp/a\tb"))
+}
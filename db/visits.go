@@ -0,0 +1,175 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// maxRecentVisits is the number of recent visits remembered for each path.
+const maxRecentVisits = 10
+
+// MaxVisitDays is the number of days visits to each path are counted for.
+const MaxVisitDays = 12 * 7
+
+// secondsPerDay is the number of seconds in a day, ignoring leap seconds.
+const secondsPerDay = 24 * 60 * 60
+
+// recentVisits is a ring buffer of the most recent visits to a path. The slot
+// for the next visit is determined by the total number of visits.
+type recentVisits [maxRecentVisits]time.Time
+
+// dailyVisits counts the visits to a path on each of the last MaxVisitDays
+// days. The slot for a day is its day number modulo MaxVisitDays.
+type dailyVisits [MaxVisitDays]uint32
+
+// DayVisits is the number of visits on a day.
+type DayVisits struct {
+	Day    time.Time `json:"day"`
+	Visits int       `json:"visits"`
+}
+
+// dayNumber returns the number of days from the Unix epoch to the calendar
+// day of t, in the location of t.
+func dayNumber(t time.Time) int64 {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+}
+
+// dayTime returns the local midnight starting the given day number.
+func dayTime(n int64) time.Time {
+	year, month, day := time.Unix(n*secondsPerDay, 0).UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// RecentVisits returns the most recent visits to the path, oldest first.
+func (w Weight) RecentVisits() []time.Time {
+	var visits []time.Time
+	for i := w.Visits; i < w.Visits+maxRecentVisits; i++ {
+		if at := w.Recent[i%maxRecentVisits]; !at.IsZero() {
+			visits = append(visits, at)
+		}
+	}
+	return visits
+}
+
+// setRecentVisits replaces the recent visits, oldest first, keeping the newest
+// ones. The number of visits should already be set.
+func (w *Weight) setRecentVisits(visits []time.Time) {
+	w.Recent = recentVisits{}
+	if w.Visits < len(visits) {
+		w.Visits = len(visits)
+	}
+	if len(visits) > maxRecentVisits {
+		visits = visits[len(visits)-maxRecentVisits:]
+	}
+	for i, at := range visits {
+		slot := w.Visits - len(visits) + i
+		w.Recent[slot%maxRecentVisits] = at
+	}
+}
+
+// DailyVisits returns the number of visits to the path on each day it was
+// visited, oldest first, for the last MaxVisitDays days it was counted.
+func (w Weight) DailyVisits() []DayVisits {
+	var visits []DayVisits
+	for day := w.LastDay - MaxVisitDays + 1; day <= w.LastDay; day++ {
+		if day < 0 {
+			continue
+		}
+		if n := w.Daily[day%MaxVisitDays]; n > 0 {
+			visits = append(visits, DayVisits{Day: dayTime(day), Visits: int(n)})
+		}
+	}
+	return visits
+}
+
+// setDailyVisits replaces the daily visit counts.
+func (w *Weight) setDailyVisits(visits []DayVisits) {
+	w.Daily = dailyVisits{}
+	w.LastDay = 0
+	for _, v := range visits {
+		if v.Visits > 0 {
+			w.countVisits(dayNumber(v.Day), uint32(v.Visits))
+		}
+	}
+}
+
+// countVisits adds n visits on the given day number. Counting a day newer than
+// the last one counted forgets the days that no longer fit, and visits on days
+// that have already been forgotten are dropped.
+func (w *Weight) countVisits(day int64, n uint32) {
+	if day < 0 {
+		return
+	}
+	if day > w.LastDay {
+		for d := w.LastDay + 1; d <= day && d <= w.LastDay+MaxVisitDays; d++ {
+			w.Daily[d%MaxVisitDays] = 0
+		}
+		w.LastDay = day
+	}
+	if w.LastDay-day < MaxVisitDays {
+		w.Daily[day%MaxVisitDays] += n
+	}
+}
+
+// visit records a visit to the path at the given time. Visits are counted on
+// the local calendar day they happened.
+func (w *Weight) visit(at time.Time) {
+	w.countVisits(dayNumber(at.Local()), 1)
+	w.Recent[w.Visits%maxRecentVisits] = at
+	w.Visits++
+	if w.FirstSeen.IsZero() || at.Before(w.FirstSeen) {
+		w.FirstSeen = at
+	}
+}
+
+// backfillVisits fills in the visit statistics of a weight that doesn't have
+// any, e.g. one written by an older version or imported from another tool, by
+// assuming it was visited once when it was last updated.
+func (w *Weight) backfillVisits() {
+	if w.Visits > 0 || w.UpdatedAt.IsZero() {
+		return
+	}
+	w.visit(w.UpdatedAt)
+}
+
+// backfillDaily fills in the daily visit counts of a weight that doesn't have
+// any, e.g. one written by an older version, from its recent visits.
+func (w *Weight) backfillDaily() {
+	if w.Daily != (dailyVisits{}) {
+		return
+	}
+	for _, at := range w.RecentVisits() {
+		w.countVisits(dayNumber(at.Local()), 1)
+	}
+}
+
+// mergeVisits combines the visit statistics of other into w.
+func (w *Weight) mergeVisits(other Weight) {
+	visits := append(w.RecentVisits(), other.RecentVisits()...)
+	sort.Slice(visits, func(i, j int) bool { return visits[i].Before(visits[j]) })
+	w.Visits += other.Visits
+	if w.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(w.FirstSeen)) {
+		w.FirstSeen = other.FirstSeen
+	}
+	w.setRecentVisits(visits)
+	for _, v := range other.DailyVisits() {
+		w.countVisits(dayNumber(v.Day), uint32(v.Visits))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/eklitzke/jump/db"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestVisits(c *C) {
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	for i := 0; i < 12; i++ {
+		handle.AdjustWeight("/foo", 1)
+	}
+	handle.AdjustWeight("/foo", -1)
+
+	w := handle.Weights["/foo"]
+	c.Assert(w.Visits, Equals, 12)
+	recent := w.RecentVisits()
+	c.Assert(recent, HasLen, 10)
+	for i := 1; i < len(recent); i++ {
+		c.Assert(recent[i].Before(recent[i-1]), Equals, false)
+	}
+	c.Assert(w.FirstSeen.Before(recent[0]), Equals, true)
+
+	entries := handle.GetWeights()
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].Visits, Equals, 12)
+	c.Assert(entries[0].Recent, DeepEquals, recent)
+}
+
+func (s *MySuite) TestImportVisits(c *C) {
+	now := time.Now().UTC()
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.AdjustWeight("/foo", 1)
+	handle.Import([]db.Entry{
+		{Path: "/foo", Weight: 1, UpdatedAt: now.Add(-time.Hour)},
+		{Path: "/bar", Weight: 1, UpdatedAt: now.Add(-time.Hour), Visits: 5, FirstSeen: now.Add(-48 * time.Hour)},
+	})
+
+	// entries without visit statistics count as one visit
+	foo := handle.Weights["/foo"]
+	c.Assert(foo.Visits, Equals, 2)
+	c.Assert(foo.FirstSeen.Equal(now.Add(-time.Hour)), Equals, true)
+	c.Assert(foo.RecentVisits(), HasLen, 2)
+
+	bar := handle.Weights["/bar"]
+	c.Assert(bar.Visits, Equals, 5)
+	c.Assert(bar.FirstSeen.Equal(now.Add(-48*time.Hour)), Equals, true)
+}
+
+func (s *MySuite) TestDailyVisits(c *C) {
+	today := time.Now()
+	handle := db.NewGobDatabase(strings.NewReader(""), db.Options{})
+	handle.Import([]db.Entry{
+		{Path: "/foo", Weight: 1, UpdatedAt: today, Visits: 30, Daily: []db.DayVisits{
+			{Day: today.AddDate(0, 0, -db.MaxVisitDays), Visits: 4},
+			{Day: today.AddDate(0, 0, -1), Visits: 20},
+			{Day: today, Visits: 10},
+		}},
+	})
+	handle.AdjustWeight("/foo", 1)
+
+	// days older than MaxVisitDays are forgotten
+	daily := handle.Weights["/foo"].DailyVisits()
+	c.Assert(daily, HasLen, 2)
+	c.Assert(daily[0].Visits, Equals, 20)
+	c.Assert(daily[1].Visits, Equals, 11)
+	y, m, d := today.Date()
+	c.Assert(daily[1].Day.Equal(time.Date(y, m, d, 0, 0, 0, 0, time.Local)), Equals, true)
+}
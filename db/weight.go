@@ -20,10 +20,21 @@ import (
 	"time"
 )
 
-// Weight represents a weight value with a timestamp.
+// Weight represents a weight value with a timestamp, and metadata about the
+// path it's for.
 type Weight struct {
 	Value     float64
 	UpdatedAt time.Time
+	Repo      string // the enclosing repository root, if any
+	Kind      Kind   // the kind of path
+
+	Visits    int          // the number of visits
+	FirstSeen time.Time    // when the path was first visited
+	Recent    recentVisits // the most recent visits
+	Daily     dailyVisits  // the number of visits on each recent day
+	LastDay   int64        // the day number of the newest day in Daily
+
+	AgedAt time.Time // when the weight was last aged, if ever
 }
 
 // NewWeight creates a new weight value with the current timestamp.
@@ -25,6 +25,7 @@ const (
 	vendorName = "jump"       // the xdg application name
 	dbName     = "db.gob"     // name of the database file
 	configName = "config.yml" // the config file name
+	backupDir  = "backups"    // the backup directory name
 )
 
 func dirOrTmp(dir string, err error) string {
@@ -39,6 +40,19 @@ func DatabasePath() string {
 	return filepath.Join(dirOrTmp(os.UserCacheDir()), vendorName, dbName)
 }
 
+// dataDir returns the XDG data directory.
+func dataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(dirOrTmp(os.UserHomeDir()), ".local", "share")
+}
+
+// BackupDir returns the path to the database backup directory.
+func BackupDir() string {
+	return filepath.Join(dataDir(), vendorName, backupDir)
+}
+
 // ConfigPath returns the path to the jump config file.
 func ConfigPath() string {
 	return filepath.Join(dirOrTmp(os.UserConfigDir()), vendorName, configName)
@@ -24,4 +24,5 @@ import (
 func (s *MySuite) TestXdg(c *C) {
 	c.Assert(db.DatabasePath(), Not(Equals), "")
 	c.Assert(db.ConfigPath(), Not(Equals), "")
+	c.Assert(db.BackupDir(), Not(Equals), "")
 }
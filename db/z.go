@@ -0,0 +1,100 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	zDbFile    = ".z"    // z database file, relative to the home directory
+	fasdDbFile = ".fasd" // fasd database file, relative to the home directory
+)
+
+// FindZDatabase returns the path to the z database.
+func FindZDatabase() string {
+	if path := os.Getenv("_Z_DATA"); path != "" {
+		return path
+	}
+	return filepath.Join(dirOrTmp(os.UserHomeDir()), zDbFile)
+}
+
+// FindFasdDatabase returns the path to the fasd database.
+func FindFasdDatabase() string {
+	if path := os.Getenv("_FASD_DATA"); path != "" {
+		return path
+	}
+	return filepath.Join(dirOrTmp(os.UserHomeDir()), fasdDbFile)
+}
+
+// LoadZDatabase loads a z database file.
+func LoadZDatabase(r io.Reader) ([]Entry, error) {
+	return loadRankTimeDatabase(r)
+}
+
+// LoadFasdDatabase loads a fasd database file, which uses the same format as
+// z.
+func LoadFasdDatabase(r io.Reader) ([]Entry, error) {
+	return loadRankTimeDatabase(r)
+}
+
+// loadRankTimeDatabase loads a database with lines of the form
+// "path|rank|time", where time is a Unix timestamp.
+func loadRankTimeDatabase(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// split from the right, since the path may contain a pipe
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 {
+			log.Warn().Str("line", line).Msg("failed to split line")
+			continue
+		}
+		n := len(fields)
+		path := strings.Join(fields[:n-2], "|")
+		weight, err := strconv.ParseFloat(fields[n-2], 64)
+		if err != nil {
+			log.Warn().Str("line", line).Msg("failed to parse rank as float64")
+			continue
+		}
+		timestamp, err := strconv.ParseInt(fields[n-1], 10, 64)
+		if err != nil {
+			log.Warn().Str("line", line).Msg("failed to parse time as int64")
+			continue
+		}
+		entries = append(entries, Entry{
+			Path:      path,
+			Weight:    weight,
+			UpdatedAt: time.Unix(timestamp, 0).UTC(),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		log.Error().Err(err).Msg("error scanning file")
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Evan Klitzke <evan@eklitzke.org>
+//
+// This file is part of jump.
+//
+// jump is free software: you can redistribute it and/or modify it under
+// the terms of the GNU General Public License as published by the Free Software
+// Foundation, either version 3 of the License, or (at your option) any later
+// version.
+//
+// jump is distributed in the hope that it will be useful, but WITHOUT ANY
+// WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+// A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// jump. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	zoxideVendor  = "zoxide" // the zoxide application name
+	zoxideDbFile  = "db.zo"  // the zoxide database file name
+	zoxideVersion = 3        // the supported zoxide database version
+
+	// maxZoxidePathLen bounds path lengths, to avoid huge allocations when
+	// reading a corrupt file.
+	maxZoxidePathLen = 1 << 16
+)
+
+// FindZoxideDatabase returns the path to the zoxide database.
+func FindZoxideDatabase() string {
+	if dir := os.Getenv("_ZO_DATA_DIR"); dir != "" {
+		return filepath.Join(dir, zoxideDbFile)
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(dirOrTmp(os.UserHomeDir()), "Library", "Application Support", zoxideVendor, zoxideDbFile)
+	}
+	return filepath.Join(dataDir(), zoxideVendor, zoxideDbFile)
+}
+
+// LoadZoxideDatabase loads a zoxide database file. The file is a bincode
+// encoded version number followed by a list of (path, rank, last accessed)
+// tuples.
+func LoadZoxideDatabase(r io.Reader) ([]Entry, error) {
+	br := bufio.NewReader(r)
+	var version uint32
+	if err := binary.Read(br, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("reading zoxide version: %w", err)
+	}
+	if version != zoxideVersion {
+		return nil, fmt.Errorf("unsupported zoxide database version %d", version)
+	}
+
+	var count uint64
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("reading zoxide entry count: %w", err)
+	}
+	var entries []Entry
+	for i := uint64(0); i < count; i++ {
+		var pathLen uint64
+		if err := binary.Read(br, binary.LittleEndian, &pathLen); err != nil {
+			return nil, fmt.Errorf("reading zoxide entry %d: %w", i, err)
+		}
+		if pathLen > maxZoxidePathLen {
+			return nil, fmt.Errorf("zoxide entry %d has invalid path length %d", i, pathLen)
+		}
+		path := make([]byte, pathLen)
+		if _, err := io.ReadFull(br, path); err != nil {
+			return nil, fmt.Errorf("reading zoxide entry %d: %w", i, err)
+		}
+		var rest struct {
+			Rank         float64
+			LastAccessed uint64
+		}
+		if err := binary.Read(br, binary.LittleEndian, &rest); err != nil {
+			return nil, fmt.Errorf("reading zoxide entry %d: %w", i, err)
+		}
+		entries = append(entries, Entry{
+			Path:      string(path),
+			Weight:    rest.Rank,
+			UpdatedAt: time.Unix(int64(rest.LastAccessed), 0).UTC(),
+		})
+	}
+	return entries, nil
+}
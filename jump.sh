@@ -29,11 +29,12 @@ j() {
     echo "  jc QUERY    jump to subdirectory matching QUERY"
     echo "  jo QUERY    open the file matching QUERY"
     echo "  jco QUERY   open the subdirectory file matching QUERY"
+    echo "  ji QUERY    interactively pick a directory matching QUERY"
     return
   fi
 
   local dest
-  dest=$(jump search "$@")
+  dest=$(jump search --cwd "$PWD" "$@")
   if [[ -n $dest ]] ; then
     _jump_print_red "$dest"
     cd "$dest" || return 1
@@ -49,7 +50,7 @@ jc() { j "$PWD" "$@"; }
 # provide feature parity with autojump.
 jo() {
   local f
-  f=$(jump search "$@")
+  f=$(jump search --files --cwd "$PWD" "$@")
   if [[ -f $f ]]; then
     _jump_print_red "$f"
     xdg-open "$f"
@@ -59,7 +60,17 @@ jo() {
 }
 
 # Likewise, but for the child directory.
-jco() { jc "$PWD" "$@"; }
+jco() { jo "$PWD" "$@"; }
+
+# Interactively pick a directory to jump to from the best matches.
+ji() {
+  local dest
+  dest=$(jump search --cwd "$PWD" --interactive "$@")
+  if [[ -n $dest ]]; then
+    _jump_print_red "$dest"
+    cd "$dest" || return 1
+  fi
+}
 
 # Run jump update
 ju() { if (( JUMP_ENABLED )); then jump update; fi; }